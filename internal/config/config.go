@@ -2,25 +2,60 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration loaded from a YAML file.
 type Config struct {
-	Organization    string   `yaml:"organization"`
-	User            string   `yaml:"user"`
-	IncludePublic   *bool    `yaml:"include_public"`
-	IncludePrivate  *bool    `yaml:"include_private"`
-	IncludeArchived *bool    `yaml:"include_archived"`
-	ExcludeRepos    []string `yaml:"exclude_repos"`
-
-	// compiledExcludes caches compiled regex patterns for ExcludeRepos.
+	APIURL           string     `yaml:"api_url"`
+	Organization     string     `yaml:"organization"`
+	User             string     `yaml:"user"`
+	Teams            []string   `yaml:"teams"`      // team slugs; organization only
+	TeamMatch        string     `yaml:"team_match"` // "any" (default) or "all"
+	IncludePublic    *bool      `yaml:"include_public"`
+	IncludePrivate   *bool      `yaml:"include_private"`
+	IncludeArchived  *bool      `yaml:"include_archived"`
+	IncludeForks     *bool      `yaml:"include_forks"`
+	IncludeTemplates *bool      `yaml:"include_templates"`
+	IncludeRepos     []string   `yaml:"include_repos"`
+	ExcludeRepos     []string   `yaml:"exclude_repos"`
+	IncludeTopics    []string   `yaml:"include_topics"`
+	ExcludeTopics    []string   `yaml:"exclude_topics"`
+	TopicMatch       string     `yaml:"topic_match"` // "any" (default) or "all"
+	Languages        []string   `yaml:"languages"`
+	ExcludeLanguages []string   `yaml:"exclude_languages"`
+	ActiveWithin     string     `yaml:"active_within"` // e.g. "180d" or "72h"
+	KeepStaleClones  *bool      `yaml:"keep_stale_clones"`
+	Jobs             int        `yaml:"jobs"`
+	CloneProtocol    string     `yaml:"clone_protocol"`
+	RateLimitMaxWait string     `yaml:"rate_limit_max_wait"` // Go duration, e.g. "5m"
+	Layout           string     `yaml:"layout"`              // "flat" (default), "ghq", or a template such as "{host}/{owner}/{repo}"
+	Owners           []Config   `yaml:"owners"`              // several owners in one workspace; replaces organization/user
+	Directory        string     `yaml:"directory"`           // owners entries only; workspace subdirectory for the owner's clones
+	GitHubApp        *GitHubApp `yaml:"github_app"`          // authenticate as a GitHub App installation instead of with a token
+
+	// compiledIncludes and compiledExcludes cache compiled regex patterns for
+	// IncludeRepos and ExcludeRepos.
+	compiledIncludes []*regexp.Regexp
 	compiledExcludes []*regexp.Regexp
 }
 
+// GitHubApp identifies a GitHub App installation to authenticate as.
+type GitHubApp struct {
+	AppID          int64  `yaml:"app_id"`
+	InstallationID int64  `yaml:"installation_id"`
+	PrivateKeyPath string `yaml:"private_key_path"` // PEM file; a relative path is resolved against the workspace
+}
+
 // Load reads a YAML configuration file from the given path and returns a parsed Config.
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -38,6 +73,118 @@ func Load(path string) (*Config, error) {
 
 // Validate checks the configuration for logical errors.
 func (c *Config) Validate() error {
+	if len(c.Owners) > 0 {
+		return c.validateOwners()
+	}
+
+	if c.Directory != "" {
+		return fmt.Errorf("directory can only be set on owners entries")
+	}
+
+	return c.validateOwner()
+}
+
+// validateOwners checks a configuration that lists its owners under owners.
+// The top level then holds only workspace-wide settings, and each entry is
+// validated as a single-owner configuration.
+func (c *Config) validateOwners() error {
+	if c.Organization != "" || c.User != "" || c.hasRepoFilters() {
+		return fmt.Errorf("owners cannot be combined with top-level organization, user, teams, or repository filters; set them on each owners entry")
+	}
+
+	if err := c.validateWorkspace(); err != nil {
+		return err
+	}
+	if c.GitHubApp != nil {
+		return fmt.Errorf("github_app cannot be combined with owners: an app installation belongs to a single organization or user")
+	}
+
+	seenOwners := make(map[string]bool)
+	for i := range c.Owners {
+		owner := &c.Owners[i]
+		if len(owner.Owners) > 0 {
+			return fmt.Errorf("owners[%d]: owners entries cannot contain owners", i)
+		}
+		if owner.APIURL != "" || owner.Jobs != 0 || owner.CloneProtocol != "" || owner.RateLimitMaxWait != "" || owner.Layout != "" || owner.GitHubApp != nil {
+			return fmt.Errorf("owners[%d]: api_url, jobs, clone_protocol, rate_limit_max_wait, layout, and github_app apply to the whole workspace and cannot be set per owner", i)
+		}
+		if err := owner.validateOwner(); err != nil {
+			return fmt.Errorf("owners[%d]: %w", i, err)
+		}
+		if owner.Directory != "" && !filepath.IsLocal(owner.Directory) {
+			return fmt.Errorf("owners[%d]: invalid directory %q: must be a relative path inside the workspace", i, owner.Directory)
+		}
+		key := strings.ToLower(owner.Owner())
+		if seenOwners[key] {
+			return fmt.Errorf("owners[%d]: owner %q is listed more than once", i, owner.Owner())
+		}
+		seenOwners[key] = true
+	}
+
+	return nil
+}
+
+// hasRepoFilters reports whether any owner-scoped setting (teams or a
+// repository filter) is set.
+func (c *Config) hasRepoFilters() bool {
+	return len(c.Teams) > 0 || c.TeamMatch != "" ||
+		c.IncludePublic != nil || c.IncludePrivate != nil || c.IncludeArchived != nil ||
+		c.IncludeForks != nil || c.IncludeTemplates != nil ||
+		len(c.IncludeRepos) > 0 || len(c.ExcludeRepos) > 0 ||
+		len(c.IncludeTopics) > 0 || len(c.ExcludeTopics) > 0 || c.TopicMatch != "" ||
+		len(c.Languages) > 0 || len(c.ExcludeLanguages) > 0 ||
+		c.ActiveWithin != "" || c.KeepStaleClones != nil
+}
+
+// validateWorkspace checks the settings that apply to every owner.
+func (c *Config) validateWorkspace() error {
+	if c.APIURL != "" {
+		parsed, err := url.Parse(c.APIURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return fmt.Errorf("invalid api_url %q: must be an absolute http or https URL", c.APIURL)
+		}
+	}
+
+	switch c.CloneProtocol {
+	case "", "https", "ssh":
+	default:
+		return fmt.Errorf("invalid clone_protocol %q: must be https or ssh", c.CloneProtocol)
+	}
+
+	if c.Jobs < 0 {
+		return fmt.Errorf("jobs must not be negative")
+	}
+
+	if _, err := parseLayout(c.Layout); err != nil {
+		return fmt.Errorf("invalid layout %q: %w", c.Layout, err)
+	}
+
+	if c.RateLimitMaxWait != "" {
+		d, err := time.ParseDuration(c.RateLimitMaxWait)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid rate_limit_max_wait %q: must be a non-negative duration such as 90s or 5m", c.RateLimitMaxWait)
+		}
+	}
+
+	if app := c.GitHubApp; app != nil {
+		if app.AppID <= 0 {
+			return fmt.Errorf("github_app.app_id must be set to the app's numeric ID")
+		}
+		if app.InstallationID <= 0 {
+			return fmt.Errorf("github_app.installation_id must be set to the installation's numeric ID")
+		}
+		if app.PrivateKeyPath == "" {
+			return fmt.Errorf("github_app.private_key_path must be set to the app's PEM private key file")
+		}
+	}
+
+	return nil
+}
+
+// validateOwner checks the owner and repository filter settings of a
+// single-owner configuration or an owners entry, along with the workspace
+// settings it may carry.
+func (c *Config) validateOwner() error {
 	if c.Organization != "" && c.User != "" {
 		return fmt.Errorf("organization and user are mutually exclusive; specify one but not both")
 	}
@@ -46,11 +193,63 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("one of organization or user is required")
 	}
 
+	if len(c.Teams) > 0 && c.Organization == "" {
+		return fmt.Errorf("teams can only be used with organization")
+	}
+
+	for _, slug := range c.Teams {
+		if strings.TrimSpace(slug) == "" || strings.Contains(slug, "/") {
+			return fmt.Errorf("invalid teams entry %q: must be a team slug such as platform-team", slug)
+		}
+	}
+
+	switch c.TeamMatch {
+	case "", "any", "all":
+	default:
+		return fmt.Errorf("invalid team_match %q: must be any or all", c.TeamMatch)
+	}
+
 	if c.IncludePublic != nil && !*c.IncludePublic &&
 		c.IncludePrivate != nil && !*c.IncludePrivate {
 		return fmt.Errorf("both include_public and include_private are false; no repositories would be included")
 	}
 
+	if err := c.validateWorkspace(); err != nil {
+		return err
+	}
+
+	if c.ActiveWithin != "" {
+		if d, err := parseAge(c.ActiveWithin); err != nil || d <= 0 {
+			return fmt.Errorf("invalid active_within %q: must be a positive number of days such as 180d, or a duration such as 72h", c.ActiveWithin)
+		}
+	}
+
+	switch c.TopicMatch {
+	case "", "any", "all":
+	default:
+		return fmt.Errorf("invalid topic_match %q: must be any or all", c.TopicMatch)
+	}
+
+	for _, topic := range slices.Concat(c.IncludeTopics, c.ExcludeTopics) {
+		if strings.TrimSpace(topic) == "" {
+			return fmt.Errorf("include_topics and exclude_topics must not contain empty topics")
+		}
+	}
+
+	for _, language := range slices.Concat(c.Languages, c.ExcludeLanguages) {
+		if strings.TrimSpace(language) == "" {
+			return fmt.Errorf("languages and exclude_languages must not contain empty languages")
+		}
+	}
+
+	for _, pattern := range c.IncludeRepos {
+		re, err := regexp.Compile(anchored(pattern))
+		if err != nil {
+			return fmt.Errorf("invalid include_repos pattern %q: %w", pattern, err)
+		}
+		c.compiledIncludes = append(c.compiledIncludes, re)
+	}
+
 	for _, pattern := range c.ExcludeRepos {
 		re, err := regexp.Compile(pattern)
 		if err != nil {
@@ -62,6 +261,20 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// OwnerConfigs returns the configuration of each owner to sync, in order:
+// the owners entries, or the configuration itself when owners is not used.
+// This should only be called after Validate().
+func (c *Config) OwnerConfigs() []*Config {
+	if len(c.Owners) == 0 {
+		return []*Config{c}
+	}
+	owners := make([]*Config, len(c.Owners))
+	for i := range c.Owners {
+		owners[i] = &c.Owners[i]
+	}
+	return owners
+}
+
 // Owner returns the configured organization or user name.
 // This should only be called after Validate() has confirmed that exactly one of
 // Organization or User is set.
@@ -72,6 +285,12 @@ func (c *Config) Owner() string {
 	return c.User
 }
 
+// MatchAllTeams returns true if a repository must belong to every team in
+// Teams, rather than to any one of them.
+func (c *Config) MatchAllTeams() bool {
+	return c.TeamMatch == "all"
+}
+
 // IsUserMode returns true if the configuration targets a user account rather than an organization.
 func (c *Config) IsUserMode() bool {
 	return c.User != ""
@@ -95,11 +314,180 @@ func (c *Config) ShouldIncludeArchived() bool {
 	return c.IncludeArchived != nil && *c.IncludeArchived
 }
 
+// ActivityWindow returns how recently a repository must have been pushed to
+// in order to be included, or 0 when active_within is not set. This should
+// only be called after Validate().
+func (c *Config) ActivityWindow() time.Duration {
+	d, _ := parseAge(c.ActiveWithin)
+	return d
+}
+
+// ShouldKeepStaleClones returns true if repositories outside active_within
+// that are already cloned should still be synced. Defaults to false when not
+// explicitly set.
+func (c *Config) ShouldKeepStaleClones() bool {
+	return c.KeepStaleClones != nil && *c.KeepStaleClones
+}
+
+// parseAge parses a number of days with a "d" suffix, such as "180d", or any
+// Go duration. An empty string is zero.
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// ShouldIncludeForks returns true if forked repositories should be included.
+// Defaults to true when not explicitly set.
+func (c *Config) ShouldIncludeForks() bool {
+	return c.IncludeForks == nil || *c.IncludeForks
+}
+
+// ShouldIncludeTemplates returns true if template repositories should be included.
+// Defaults to true when not explicitly set.
+func (c *Config) ShouldIncludeTemplates() bool {
+	return c.IncludeTemplates == nil || *c.IncludeTemplates
+}
+
+// JobCount returns the number of repositories to process concurrently.
+// Defaults to 1 (serial processing) when not explicitly set.
+func (c *Config) JobCount() int {
+	if c.Jobs < 1 {
+		return 1
+	}
+	return c.Jobs
+}
+
+// Protocol returns the protocol used for new clones: "https" or "ssh".
+// Defaults to "https" when not explicitly set.
+func (c *Config) Protocol() string {
+	if c.CloneProtocol == "" {
+		return "https"
+	}
+	return c.CloneProtocol
+}
+
+// Layout placeholders. {repo} is always the last segment of a layout.
+const (
+	LayoutHost  = "{host}"
+	LayoutOwner = "{owner}"
+	LayoutRepo  = "{repo}"
+)
+
+// layoutPresets maps named layouts to their templates.
+var layoutPresets = map[string]string{
+	"":     LayoutRepo,
+	"flat": LayoutRepo,
+	"ghq":  LayoutHost + "/" + LayoutOwner + "/" + LayoutRepo,
+}
+
+// LayoutDirs returns the directory segments of the layout above each
+// repository, such as ["{host}", "{owner}"] for ghq. It is empty for the flat
+// layout. This should only be called after Validate().
+func (c *Config) LayoutDirs() []string {
+	dirs, _ := parseLayout(c.Layout)
+	return dirs
+}
+
+// parseLayout resolves a named layout or template into its directory
+// segments. Every segment is either a placeholder or a plain directory name,
+// and the template must end with {repo}.
+func parseLayout(layout string) ([]string, error) {
+	if preset, ok := layoutPresets[layout]; ok {
+		layout = preset
+	}
+	segments := strings.Split(layout, "/")
+	if segments[len(segments)-1] != LayoutRepo {
+		return nil, fmt.Errorf("must be flat, ghq, or a template ending in %s", LayoutRepo)
+	}
+	dirs := segments[:len(segments)-1]
+	for _, segment := range dirs {
+		switch {
+		case segment == LayoutHost || segment == LayoutOwner:
+		case strings.ContainsAny(segment, "{}"):
+			return nil, fmt.Errorf("segment %q must be exactly %s or %s, or contain no placeholder", segment, LayoutHost, LayoutOwner)
+		case !filepath.IsLocal(segment) || strings.ContainsAny(segment, `/\`):
+			return nil, fmt.Errorf("segment %q is not a directory name", segment)
+		}
+	}
+	return dirs, nil
+}
+
+// IsLayoutPlaceholder reports whether a layout segment is a placeholder
+// rather than a fixed directory name.
+func IsLayoutPlaceholder(segment string) bool {
+	return segment == LayoutHost || segment == LayoutOwner || segment == LayoutRepo
+}
+
+// ExpandLayout fills in the placeholders of layout directory segments for
+// one owner and returns them as a relative path; "" for the flat layout.
+func ExpandLayout(dirs []string, host, owner string) string {
+	expanded := make([]string, len(dirs))
+	for i, segment := range dirs {
+		switch segment {
+		case LayoutHost:
+			expanded[i] = host
+		case LayoutOwner:
+			expanded[i] = owner
+		default:
+			expanded[i] = segment
+		}
+	}
+	return filepath.Join(expanded...)
+}
+
+// DefaultRateLimitMaxWait is used when rate_limit_max_wait is not set.
+const DefaultRateLimitMaxWait = 5 * time.Minute
+
+// RateLimitWait returns the maximum total time to wait for GitHub API rate
+// limits to reset. Defaults to DefaultRateLimitMaxWait when not explicitly set;
+// zero disables waiting. This should only be called after Validate().
+func (c *Config) RateLimitWait() time.Duration {
+	if c.RateLimitMaxWait == "" {
+		return DefaultRateLimitMaxWait
+	}
+	d, _ := time.ParseDuration(c.RateLimitMaxWait)
+	return d
+}
+
+// IsIncluded checks whether the given repository name matches any pattern in
+// IncludeRepos as a whole, so "api" does not include "api-gateway". Every name
+// is included when IncludeRepos is empty.
+func (c *Config) IsIncluded(repoName string) bool {
+	if len(c.IncludeRepos) == 0 {
+		return true
+	}
+	patterns := make([]string, len(c.IncludeRepos))
+	for i, pattern := range c.IncludeRepos {
+		patterns[i] = anchored(pattern)
+	}
+	return matchesAny(patterns, c.compiledIncludes, repoName)
+}
+
+// anchored wraps a pattern so that it must match the whole repository name.
+func anchored(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
+
 // IsExcluded checks whether the given repository name matches any pattern in ExcludeRepos.
 func (c *Config) IsExcluded(repoName string) bool {
+	return matchesAny(c.ExcludeRepos, c.compiledExcludes, repoName)
+}
+
+// matchesAny reports whether repoName matches any of patterns, using their
+// compiled forms when available.
+func matchesAny(patterns []string, compiled []*regexp.Regexp, repoName string) bool {
 	// Use cached compiled patterns if available (after Validate has been called)
-	if len(c.compiledExcludes) > 0 {
-		for _, re := range c.compiledExcludes {
+	if len(compiled) > 0 {
+		for _, re := range compiled {
 			if re.MatchString(repoName) {
 				return true
 			}
@@ -107,7 +495,7 @@ func (c *Config) IsExcluded(repoName string) bool {
 		return false
 	}
 	// Fallback: compile on the fly (before Validate is called)
-	for _, pattern := range c.ExcludeRepos {
+	for _, pattern := range patterns {
 		re, err := regexp.Compile(pattern)
 		if err != nil {
 			continue
@@ -118,3 +506,43 @@ func (c *Config) IsExcluded(repoName string) bool {
 	}
 	return false
 }
+
+// IsIncludedByTopics reports whether a repository with the given topics passes
+// include_topics. With topic_match "any" (the default) the repository needs at
+// least one of the listed topics; with "all" it needs every one. Always true
+// when include_topics is empty.
+func (c *Config) IsIncludedByTopics(topics []string) bool {
+	return len(c.IncludeTopics) == 0 || c.matchTopics(c.IncludeTopics, topics)
+}
+
+// IsExcludedByTopics reports whether a repository with the given topics is
+// excluded by exclude_topics, using the same topic_match semantics as
+// IsIncludedByTopics. Always false when exclude_topics is empty.
+func (c *Config) IsExcludedByTopics(topics []string) bool {
+	return len(c.ExcludeTopics) > 0 && c.matchTopics(c.ExcludeTopics, topics)
+}
+
+// matchTopics applies topic_match to want against a repository's topics.
+// GitHub stores topics in lowercase, so comparison ignores case.
+func (c *Config) matchTopics(want, topics []string) bool {
+	has := func(topic string) bool {
+		return slices.ContainsFunc(topics, func(t string) bool { return strings.EqualFold(t, topic) })
+	}
+	if c.TopicMatch == "all" {
+		return !slices.ContainsFunc(want, func(t string) bool { return !has(t) })
+	}
+	return slices.ContainsFunc(want, has)
+}
+
+// IsIncludedByLanguage reports whether a repository whose primary language is
+// language passes the languages and exclude_languages filters. Names are
+// compared without regard to case. A repository with no detected language
+// ("") never matches languages, so it is only included when languages is
+// empty.
+func (c *Config) IsIncludedByLanguage(language string) bool {
+	matches := func(l string) bool { return strings.EqualFold(l, language) }
+	if len(c.Languages) > 0 && !slices.ContainsFunc(c.Languages, matches) {
+		return false
+	}
+	return !slices.ContainsFunc(c.ExcludeLanguages, matches)
+}
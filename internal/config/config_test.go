@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 //go:fix inline
@@ -110,6 +111,50 @@ func TestValidateInvalidRegexPattern(t *testing.T) {
 	}
 }
 
+func TestValidateInvalidIncludePattern(t *testing.T) {
+	cfg := &Config{
+		Organization: "my-org",
+		IncludeRepos: []string{"(unclosed"},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for invalid include_repos pattern")
+	}
+}
+
+func TestIsIncluded(t *testing.T) {
+	if !(&Config{}).IsIncluded("anything") {
+		t.Error("expected every repo to be included without include_repos")
+	}
+
+	cfg := &Config{Organization: "my-org", IncludeRepos: []string{"billing", "svc-.*", "^api$"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name     string
+		included bool
+	}{
+		{"billing", true},
+		{"billing-old", false},
+		{"svc-orders", true},
+		{"my-svc-orders", false},
+		{"api", true},
+		{"api-gateway", false},
+		{"my-api", false},
+		{"web", false},
+	}
+	for _, tt := range tests {
+		if got := cfg.IsIncluded(tt.name); got != tt.included {
+			t.Errorf("IsIncluded(%q) = %v, want %v", tt.name, got, tt.included)
+		}
+	}
+
+	// Before Validate, patterns are compiled on the fly but still anchored.
+	if (&Config{IncludeRepos: []string{"api"}}).IsIncluded("api-gateway") {
+		t.Error("expected api not to include api-gateway before Validate")
+	}
+}
+
 func TestIsExcludedMatching(t *testing.T) {
 	cfg := &Config{
 		ExcludeRepos: []string{"legacy-repo", "^sandbox-", "-archive$"},
@@ -259,3 +304,360 @@ include_archived: true
 		t.Error("ShouldIncludeArchived() = false, want true")
 	}
 }
+
+func TestJobCountDefault(t *testing.T) {
+	cfg := &Config{Organization: "my-org"}
+	if got := cfg.JobCount(); got != 1 {
+		t.Errorf("JobCount() = %d, want 1 (default)", got)
+	}
+}
+
+func TestLoadConfigWithJobs(t *testing.T) {
+	path := writeTestConfig(t, "organization: my-org\njobs: 8\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if got := cfg.JobCount(); got != 8 {
+		t.Errorf("JobCount() = %d, want 8", got)
+	}
+}
+
+func TestValidateNegativeJobs(t *testing.T) {
+	cfg := &Config{Organization: "my-org", Jobs: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for negative jobs")
+	}
+}
+
+func TestValidateAPIURL(t *testing.T) {
+	valid := &Config{Organization: "my-org", APIURL: "https://ghes.example.com/api/v3"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error for valid api_url: %v", err)
+	}
+	invalid := &Config{Organization: "my-org", APIURL: "ghes.example.com/api/v3"}
+	if err := invalid.Validate(); err == nil {
+		t.Fatal("expected error for api_url without scheme")
+	}
+}
+
+func TestCloneProtocol(t *testing.T) {
+	cfg := &Config{Organization: "my-org"}
+	if got := cfg.Protocol(); got != "https" {
+		t.Errorf("Protocol() = %q, want https (default)", got)
+	}
+	cfg.CloneProtocol = "ssh"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Protocol(); got != "ssh" {
+		t.Errorf("Protocol() = %q, want ssh", got)
+	}
+}
+
+func TestValidateInvalidCloneProtocol(t *testing.T) {
+	cfg := &Config{Organization: "my-org", CloneProtocol: "git"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for invalid clone_protocol")
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	cfg := &Config{Organization: "my-org"}
+	if got := cfg.RateLimitWait(); got != DefaultRateLimitMaxWait {
+		t.Errorf("RateLimitWait() = %v, want %v (default)", got, DefaultRateLimitMaxWait)
+	}
+	cfg.RateLimitMaxWait = "90s"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.RateLimitWait(); got != 90*time.Second {
+		t.Errorf("RateLimitWait() = %v, want 90s", got)
+	}
+	cfg.RateLimitMaxWait = "0"
+	if got := cfg.RateLimitWait(); got != 0 {
+		t.Errorf("RateLimitWait() = %v, want 0", got)
+	}
+}
+
+func TestValidateInvalidRateLimitMaxWait(t *testing.T) {
+	for _, value := range []string{"5", "soon", "-1m"} {
+		cfg := &Config{Organization: "my-org", RateLimitMaxWait: value}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for rate_limit_max_wait %q", value)
+		}
+	}
+}
+
+func TestTopicMatching(t *testing.T) {
+	topics := []string{"team-payments", "go"}
+	tests := []struct {
+		name         string
+		cfg          Config
+		wantIncluded bool
+		wantExcluded bool
+	}{
+		{"no filters", Config{}, true, false},
+		{"include any", Config{IncludeTopics: []string{"terraform", "go"}}, true, false},
+		{"include any miss", Config{IncludeTopics: []string{"terraform"}}, false, false},
+		{"include all", Config{IncludeTopics: []string{"go", "team-payments"}, TopicMatch: "all"}, true, false},
+		{"include all miss", Config{IncludeTopics: []string{"go", "terraform"}, TopicMatch: "all"}, false, false},
+		{"exclude any", Config{ExcludeTopics: []string{"deprecated", "GO"}}, true, true},
+		{"exclude all miss", Config{ExcludeTopics: []string{"deprecated", "go"}, TopicMatch: "all"}, true, false},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.IsIncludedByTopics(topics); got != tt.wantIncluded {
+			t.Errorf("%s: IsIncludedByTopics = %t, want %t", tt.name, got, tt.wantIncluded)
+		}
+		if got := tt.cfg.IsExcludedByTopics(topics); got != tt.wantExcluded {
+			t.Errorf("%s: IsExcludedByTopics = %t, want %t", tt.name, got, tt.wantExcluded)
+		}
+	}
+}
+
+func TestValidateTopics(t *testing.T) {
+	if err := (&Config{Organization: "my-org", TopicMatch: "some"}).Validate(); err == nil {
+		t.Error("expected error for invalid topic_match")
+	}
+	if err := (&Config{Organization: "my-org", IncludeTopics: []string{""}}).Validate(); err == nil {
+		t.Error("expected error for empty topic")
+	}
+	if err := (&Config{Organization: "my-org", ExcludeTopics: []string{"deprecated"}, TopicMatch: "all"}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestShouldIncludeForksAndTemplates(t *testing.T) {
+	cfg := &Config{}
+	if !cfg.ShouldIncludeForks() || !cfg.ShouldIncludeTemplates() {
+		t.Error("expected forks and templates to be included by default")
+	}
+	cfg = &Config{IncludeForks: new(false), IncludeTemplates: new(false)}
+	if cfg.ShouldIncludeForks() || cfg.ShouldIncludeTemplates() {
+		t.Error("expected forks and templates to be excluded when set to false")
+	}
+}
+
+func TestIsIncludedByLanguage(t *testing.T) {
+	cfg := &Config{Languages: []string{"Go", "hcl"}, ExcludeLanguages: []string{"go"}}
+	if cfg.IsIncludedByLanguage("Go") {
+		t.Error("exclude_languages should win over languages")
+	}
+	if !cfg.IsIncludedByLanguage("HCL") {
+		t.Error("expected HCL to be included")
+	}
+	if cfg.IsIncludedByLanguage("") {
+		t.Error("expected a repo without a language to be excluded when languages is set")
+	}
+	if !(&Config{}).IsIncludedByLanguage("") {
+		t.Error("expected every repo to be included without language filters")
+	}
+	if err := (&Config{Organization: "my-org", ExcludeLanguages: []string{" "}}).Validate(); err == nil {
+		t.Error("expected error for empty language")
+	}
+}
+
+func TestActivityWindow(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"180d", 180 * 24 * time.Hour},
+		{"72h", 72 * time.Hour},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Organization: "my-org", ActiveWithin: tt.value}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.value, err)
+		}
+		if got := cfg.ActivityWindow(); got != tt.want {
+			t.Errorf("ActivityWindow(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+	for _, value := range []string{"180", "d", "-5d", "0d", "six months"} {
+		cfg := &Config{Organization: "my-org", ActiveWithin: value}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for active_within %q", value)
+		}
+	}
+}
+
+func TestValidateTeams(t *testing.T) {
+	if err := (&Config{User: "octocat", Teams: []string{"platform"}}).Validate(); err == nil {
+		t.Error("expected error for teams in user mode")
+	}
+	for _, slug := range []string{"", "acme/platform"} {
+		if err := (&Config{Organization: "acme", Teams: []string{slug}}).Validate(); err == nil {
+			t.Errorf("expected error for team slug %q", slug)
+		}
+	}
+	if err := (&Config{Organization: "acme", Teams: []string{"platform"}, TeamMatch: "both"}).Validate(); err == nil {
+		t.Error("expected error for invalid team_match")
+	}
+	cfg := &Config{Organization: "acme", Teams: []string{"platform", "sre"}, TeamMatch: "all"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.MatchAllTeams() {
+		t.Error("expected MatchAllTeams with team_match all")
+	}
+}
+
+func TestLoadConfigWithOwners(t *testing.T) {
+	path := writeTestConfig(t, `
+jobs: 4
+owners:
+  - organization: acme
+    directory: acme
+    exclude_repos:
+      - "^sandbox-"
+  - user: octocat
+    directory: personal
+    include_forks: false
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	owners := cfg.OwnerConfigs()
+	if len(owners) != 2 {
+		t.Fatalf("expected 2 owners, got %d", len(owners))
+	}
+	if owners[0].Owner() != "acme" || owners[0].Directory != "acme" || !owners[0].IsExcluded("sandbox-1") {
+		t.Errorf("unexpected first owner: %+v", owners[0])
+	}
+	if !owners[1].IsUserMode() || owners[1].Directory != "personal" || owners[1].ShouldIncludeForks() {
+		t.Errorf("unexpected second owner: %+v", owners[1])
+	}
+	if cfg.JobCount() != 4 {
+		t.Errorf("JobCount() = %d, want 4", cfg.JobCount())
+	}
+}
+
+func TestOwnerConfigsSingleOwner(t *testing.T) {
+	cfg := &Config{Organization: "acme"}
+	if owners := cfg.OwnerConfigs(); len(owners) != 1 || owners[0] != cfg {
+		t.Errorf("expected the configuration itself as the only owner, got %v", owners)
+	}
+}
+
+func TestValidateOwners(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"top-level organization", Config{Organization: "acme", Owners: []Config{{User: "octocat"}}}},
+		{"top-level filter", Config{ExcludeRepos: []string{"x"}, Owners: []Config{{User: "octocat"}}}},
+		{"owner without type", Config{Owners: []Config{{Directory: "acme"}}}},
+		{"owner with both types", Config{Owners: []Config{{Organization: "acme", User: "octocat"}}}},
+		{"workspace setting on owner", Config{Owners: []Config{{Organization: "acme", Jobs: 2}}}},
+		{"nested owners", Config{Owners: []Config{{Organization: "acme", Owners: []Config{{User: "octocat"}}}}}},
+		{"absolute directory", Config{Owners: []Config{{Organization: "acme", Directory: "/srv/acme"}}}},
+		{"directory outside workspace", Config{Owners: []Config{{Organization: "acme", Directory: "../acme"}}}},
+		{"duplicate owner", Config{Owners: []Config{{Organization: "acme"}, {Organization: "ACME", Directory: "other"}}}},
+		{"invalid owner filter", Config{Owners: []Config{{Organization: "acme", TopicMatch: "some"}}}},
+		{"directory without owners", Config{Organization: "acme", Directory: "acme"}},
+		{"github_app with owners", Config{GitHubApp: &GitHubApp{AppID: 1, InstallationID: 2, PrivateKeyPath: "app.pem"}, Owners: []Config{{Organization: "acme"}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+
+	shared := &Config{Owners: []Config{{Organization: "acme"}, {User: "octocat"}}}
+	if err := shared.Validate(); err != nil {
+		t.Errorf("owners sharing the workspace root should be valid: %v", err)
+	}
+}
+
+func TestLayoutDirs(t *testing.T) {
+	tests := []struct {
+		layout string
+		want   []string
+	}{
+		{"", nil},
+		{"flat", nil},
+		{"ghq", []string{"{host}", "{owner}"}},
+		{"{owner}/{repo}", []string{"{owner}"}},
+		{"src/{host}/{owner}/{repo}", []string{"src", "{host}", "{owner}"}},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Organization: "acme", Layout: tt.layout}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("layout %q: unexpected error: %v", tt.layout, err)
+			continue
+		}
+		got := cfg.LayoutDirs()
+		if len(got) != len(tt.want) {
+			t.Errorf("LayoutDirs(%q) = %v, want %v", tt.layout, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("LayoutDirs(%q) = %v, want %v", tt.layout, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestValidateInvalidLayout(t *testing.T) {
+	for _, layout := range []string{"tree", "{host}/{owner}", "{repo}/{owner}", "{owner}-{repo}", "{host}/x-{owner}/{repo}", "../{repo}", "a//{repo}", "/{repo}"} {
+		if err := (&Config{Organization: "acme", Layout: layout}).Validate(); err == nil {
+			t.Errorf("expected error for layout %q", layout)
+		}
+	}
+	if err := (&Config{Layout: "ghq", Owners: []Config{{Organization: "acme", Layout: "flat"}}}).Validate(); err == nil {
+		t.Error("expected error for layout on an owners entry")
+	}
+}
+
+func TestExpandLayout(t *testing.T) {
+	if got := ExpandLayout(nil, "github.com", "acme"); got != "" {
+		t.Errorf("ExpandLayout(flat) = %q, want empty", got)
+	}
+	got := ExpandLayout([]string{"src", LayoutHost, LayoutOwner}, "github.com", "acme")
+	if want := filepath.Join("src", "github.com", "acme"); got != want {
+		t.Errorf("ExpandLayout() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadConfigWithGitHubApp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ghorgsync")
+	data := "organization: acme\ngithub_app:\n  app_id: 123\n  installation_id: 456\n  private_key_path: keys/app.pem\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+	want := GitHubApp{AppID: 123, InstallationID: 456, PrivateKeyPath: "keys/app.pem"}
+	if cfg.GitHubApp == nil || *cfg.GitHubApp != want {
+		t.Errorf("GitHubApp = %+v, want %+v", cfg.GitHubApp, want)
+	}
+}
+
+func TestValidateInvalidGitHubApp(t *testing.T) {
+	for _, app := range []GitHubApp{
+		{InstallationID: 456, PrivateKeyPath: "app.pem"},
+		{AppID: 123, PrivateKeyPath: "app.pem"},
+		{AppID: 123, InstallationID: 456},
+	} {
+		cfg := &Config{Organization: "acme", GitHubApp: &app}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected validation error for %+v", app)
+		}
+	}
+}
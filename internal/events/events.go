@@ -0,0 +1,114 @@
+// Package events writes the newline-delimited JSON event log requested with
+// --events-file. Each line is one Event; the payload shape depends on Type.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of event.
+type Type string
+
+const (
+	InventoryLoaded Type = "inventory-loaded" // repository list fetched and filtered
+	APIRequest      Type = "api-request"      // one GitHub API request completed
+	RepoStarted     Type = "repo-started"     // work on a repository began
+	GitCommand      Type = "git-command"      // one git operation completed
+	RepoFinished    Type = "repo-finished"    // work on a repository finished
+	CleanupPlanned  Type = "cleanup-planned"  // --clean selected ignored content
+	Finding         Type = "finding"          // a local entry or repository finding was reported
+	Summary         Type = "summary"          // final counts
+)
+
+// FindingData is the payload of a Finding event.
+type FindingData struct {
+	Kind   string `json:"kind"` // e.g. "unknown", "collision", "protocol-mismatch"
+	Detail string `json:"detail,omitempty"`
+}
+
+// Event is one line of the event log.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	Repo string    `json:"repo,omitempty"`
+	Data any       `json:"data,omitempty"`
+}
+
+// Sink serialises events to a writer. It is safe for concurrent use, and a nil
+// *Sink discards every event so callers need not check whether logging is on.
+type Sink struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+	now    func() time.Time
+	err    error
+}
+
+// NewSink creates a sink writing to w.
+func NewSink(w io.Writer) *Sink {
+	return &Sink{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// Open creates a sink for an --events-file target: either "fd:N" for an
+// inherited file descriptor or a file path, which is created or truncated.
+func Open(target string) (*Sink, error) {
+	if fdText, ok := strings.CutPrefix(target, "fd:"); ok {
+		fd, err := strconv.Atoi(fdText)
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("invalid events file descriptor %q", target)
+		}
+		f := os.NewFile(uintptr(fd), target)
+		if f == nil {
+			return nil, fmt.Errorf("invalid events file descriptor %q", target)
+		}
+		s := NewSink(f)
+		s.closer = f
+		return s, nil
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening events file: %w", err)
+	}
+	s := NewSink(f)
+	s.closer = f
+	return s, nil
+}
+
+// Emit writes one event. The first write error is retained and reported by
+// Close; later events are dropped.
+func (s *Sink) Emit(t Type, repo string, data any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.err = s.enc.Encode(Event{Type: t, Time: s.now().UTC(), Repo: repo, Data: data})
+}
+
+// Close closes the underlying file, if any, and returns the first write error.
+func (s *Sink) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer != nil {
+		if err := s.closer.Close(); err != nil && s.err == nil {
+			s.err = err
+		}
+	}
+	if s.err != nil {
+		return fmt.Errorf("writing events: %w", s.err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSink_WritesOneJSONObjectPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSink(&buf)
+	s.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	s.Emit(RepoStarted, "alpha", nil)
+	s.Emit(Summary, "", map[string]int{"total": 1})
+
+	scanner := bufio.NewScanner(&buf)
+	var lines []Event
+	for scanner.Scan() {
+		var e map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line is not valid JSON: %v: %s", err, scanner.Text())
+		}
+		lines = append(lines, Event{Type: Type(e["type"].(string))})
+		if e["time"] != "2024-01-02T03:04:05Z" {
+			t.Errorf("unexpected time: %v", e["time"])
+		}
+	}
+	if len(lines) != 2 || lines[0].Type != RepoStarted || lines[1].Type != Summary {
+		t.Fatalf("unexpected events: %+v", lines)
+	}
+}
+
+func TestSink_NilIsNoop(t *testing.T) {
+	var s *Sink
+	s.Emit(Finding, "repo", nil)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close on nil sink returned error: %v", err)
+	}
+}
+
+func TestSink_ConcurrentEmitsDoNotInterleave(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSink(&buf)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() { s.Emit(GitCommand, "repo", map[string]string{"args": "git fetch --all --prune"}) })
+	}
+	wg.Wait()
+
+	scanner := bufio.NewScanner(&buf)
+	count := 0
+	for scanner.Scan() {
+		if !json.Valid(scanner.Bytes()) {
+			t.Fatalf("interleaved line: %s", scanner.Text())
+		}
+		count++
+	}
+	if count != 50 {
+		t.Fatalf("expected 50 lines, got %d", count)
+	}
+}
+
+func TestOpen_PathAndInvalidDescriptor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	s.Emit(InventoryLoaded, "", nil)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"type":"inventory-loaded"`)) {
+		t.Fatalf("expected event in file, got %s", data)
+	}
+
+	if _, err := Open("fd:abc"); err == nil {
+		t.Fatal("expected error for invalid descriptor")
+	}
+}
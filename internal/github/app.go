@@ -0,0 +1,190 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+)
+
+// tokenRefreshMargin is how long before expiry an installation token is
+// replaced, so a token handed to git does not expire mid-operation.
+const tokenRefreshMargin = 5 * time.Minute
+
+// AppAuth identifies a GitHub App installation to authenticate as.
+type AppAuth struct {
+	AppID          int64
+	InstallationID int64
+	Key            *rsa.PrivateKey
+}
+
+// LoadAppKey reads a GitHub App private key from a PEM file. GitHub issues
+// PKCS#1 keys; PKCS#8 keys are accepted as well.
+func LoadAppKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading GitHub App private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key %s is not PEM encoded", path)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing GitHub App private key %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// appJWT returns the RS256-signed JSON Web Token that authenticates as the
+// app itself. It is issued a minute in the past to allow for clock drift and
+// expires within GitHub's ten-minute limit.
+func appJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(struct {
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+		Issuer    string `json:"iss"`
+	}{now.Add(-time.Minute).Unix(), now.Add(9 * time.Minute).Unix(), strconv.FormatInt(appID, 10)})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing GitHub App JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// appTokenSource exchanges the app's JWT for installation access tokens and
+// caches each one until shortly before it expires. Safe for concurrent use.
+type appTokenSource struct {
+	auth AppAuth
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// UseApp authenticates as a GitHub App installation: every request uses an
+// installation access token, minted on first use and refreshed before it
+// expires, instead of the token passed to NewClient.
+func (c *Client) UseApp(auth AppAuth) {
+	c.app = &appTokenSource{auth: auth}
+}
+
+// UsesApp reports whether the client authenticates as a GitHub App installation.
+func (c *Client) UsesApp() bool {
+	return c.app != nil
+}
+
+// Token returns the token requests are authenticated with: the token passed
+// to NewClient or, with UseApp, a current installation access token.
+func (c *Client) Token() (string, error) {
+	if c.app == nil {
+		return c.token, nil
+	}
+	c.app.mu.Lock()
+	defer c.app.mu.Unlock()
+	if c.app.token != "" && c.now().Before(c.app.expiresAt.Add(-tokenRefreshMargin)) {
+		return c.app.token, nil
+	}
+	token, expiresAt, err := c.createInstallationToken()
+	if err != nil {
+		return "", err
+	}
+	c.app.token, c.app.expiresAt = token, expiresAt
+	return token, nil
+}
+
+// createInstallationToken requests a new installation access token.
+func (c *Client) createInstallationToken() (string, time.Time, error) {
+	jwt, err := appJWT(c.app.auth.AppID, c.app.auth.Key, c.now())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", c.baseURL, c.app.auth.InstallationID)
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	c.verbosefSafe("api request: %s %s (GitHub App %d installation token)", req.Method, sanitizeRequestURL(url), c.app.auth.AppID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.observeRequest(req.Method, url, 0, err)
+		return "", time.Time{}, fmt.Errorf("requesting installation token: %w", err)
+	}
+	defer resp.Body.Close()
+	c.observeRequest(req.Method, url, resp.StatusCode, nil)
+	c.verbosefSafe("api response: %s %s status=%d", req.Method, sanitizeRequestURL(url), resp.StatusCode)
+
+	// The body holds the token, so it is never traced.
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("GitHub App installation token request failed (HTTP %d): check app_id, installation_id, and the private key", resp.StatusCode)
+	}
+	var created struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil || created.Token == "" {
+		return "", time.Time{}, fmt.Errorf("decoding installation token response: unexpected body")
+	}
+	c.verbosefSafe("api auth: installation token expires at %s", created.ExpiresAt.Format(time.RFC3339))
+	return created.Token, created.ExpiresAt, nil
+}
+
+// ListInstallationRepos lists the repositories the app installation can
+// access that belong to owner.
+func (c *Client) ListInstallationRepos(owner string) ([]model.RepoInfo, error) {
+	repos, err := c.listRepoPages(c.baseURL+"/installation/repositories?per_page=100&page=1", decodeInstallationRepos)
+	if err != nil {
+		return nil, err
+	}
+	var listed []model.RepoInfo
+	for _, r := range repos {
+		if strings.EqualFold(r.Owner.Login, owner) {
+			listed = append(listed, r.info())
+		}
+	}
+	return listed, nil
+}
+
+// decodeInstallationRepos decodes a page of /installation/repositories, which
+// wraps the repositories in an object.
+func decodeInstallationRepos(body []byte) ([]ghRepo, error) {
+	var page struct {
+		Repositories []ghRepo `json:"repositories"`
+	}
+	err := json.Unmarshal(body, &page)
+	return page.Repositories, err
+}
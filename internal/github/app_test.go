@@ -0,0 +1,187 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAppKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestAppJWT(t *testing.T) {
+	key := testAppKey(t)
+	now := time.Unix(1_700_000_000, 0)
+	jwt, err := appJWT(123, key, now)
+	if err != nil {
+		t.Fatalf("appJWT returned error: %v", err)
+	}
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected three JWT segments, got %q", jwt)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims struct {
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+		Issuer    string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != "123" || claims.IssuedAt != now.Unix()-60 || claims.ExpiresAt-claims.IssuedAt > 600 {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestClientToken_InstallationTokenRefresh(t *testing.T) {
+	minted := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/app/installations/456/access_tokens" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ey") {
+			t.Fatalf("expected a JWT bearer, got %q", r.Header.Get("Authorization"))
+		}
+		minted++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"ghs_token%d","expires_at":"2024-05-01T13:00:00Z"}`, minted)
+	}))
+	defer server.Close()
+
+	var logs []string
+	client := NewClient(server.URL, "", func(format string, args ...any) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}, nil)
+	client.httpClient = server.Client()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client.now = func() time.Time { return now }
+	client.UseApp(AppAuth{AppID: 123, InstallationID: 456, Key: testAppKey(t)})
+
+	for range 2 {
+		if token, err := client.Token(); err != nil || token != "ghs_token1" {
+			t.Fatalf("Token() = %q, %v; want the first installation token", token, err)
+		}
+	}
+	now = now.Add(56 * time.Minute)
+	if token, err := client.Token(); err != nil || token != "ghs_token2" {
+		t.Fatalf("Token() = %q, %v; want a refreshed token near expiry", token, err)
+	}
+	if strings.Contains(strings.Join(logs, "\n"), "ghs_token") {
+		t.Errorf("installation token leaked in verbose logs: %v", logs)
+	}
+}
+
+func TestClientToken_InstallationTokenRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", nil, nil)
+	client.httpClient = server.Client()
+	client.UseApp(AppAuth{AppID: 123, InstallationID: 456, Key: testAppKey(t)})
+	if _, err := client.ListOrgRepos("acme"); err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("expected the token request failure, got %v", err)
+	}
+}
+
+func TestListInstallationRepos(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/installations/456/access_tokens":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"token":"ghs_abc","expires_at":"2999-01-01T00:00:00Z"}`)
+		case "/installation/repositories":
+			if got := r.Header.Get("Authorization"); got != "Bearer ghs_abc" {
+				t.Fatalf("unexpected Authorization header: %q", got)
+			}
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/installation/repositories?per_page=100&page=2>; rel="next"`, server.URL))
+				fmt.Fprint(w, `{"total_count":3,"repositories":[{"id":1,"name":"api","owner":{"login":"Acme"}},{"id":2,"name":"other","owner":{"login":"globex"}}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"total_count":3,"repositories":[{"id":3,"name":"web","private":true,"owner":{"login":"acme"}}]}`)
+		default:
+			t.Fatalf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", nil, nil)
+	client.httpClient = server.Client()
+	client.UseApp(AppAuth{AppID: 123, InstallationID: 456, Key: testAppKey(t)})
+
+	repos, err := client.ListInstallationRepos("acme")
+	if err != nil {
+		t.Fatalf("ListInstallationRepos returned error: %v", err)
+	}
+	if len(repos) != 2 || repos[0].Name != "api" || repos[1].Name != "web" || !repos[1].IsPrivate {
+		t.Errorf("expected acme's api and private web, got %+v", repos)
+	}
+}
+
+func TestLoadAppKey(t *testing.T) {
+	key := testAppKey(t)
+	dir := t.TempDir()
+
+	pkcs1 := filepath.Join(dir, "pkcs1.pem")
+	if err := os.WriteFile(pkcs1, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8 := filepath.Join(dir, "pkcs8.pem")
+	if err := os.WriteFile(pkcs8, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{pkcs1, pkcs8} {
+		loaded, err := LoadAppKey(path)
+		if err != nil || !loaded.Equal(key) {
+			t.Errorf("LoadAppKey(%s) = %v, %v", filepath.Base(path), loaded != nil, err)
+		}
+	}
+
+	garbage := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAppKey(garbage); err == nil {
+		t.Error("expected an error for a file that is not PEM encoded")
+	}
+}
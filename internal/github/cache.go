@@ -0,0 +1,129 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cacheVersion identifies the layout of the cache file. A file with another
+// version is ignored and rewritten.
+const cacheVersion = 1
+
+// Cache persists successful API responses with their validators so later runs
+// can send conditional requests and, with --offline, run without the API.
+// It is safe for concurrent use.
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]CacheEntry
+	changed bool
+}
+
+// CacheEntry is one cached response, keyed by its sanitized request URL.
+type CacheEntry struct {
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	Link         string          `json:"link,omitempty"` // pagination header
+	Body         json.RawMessage `json:"body"`
+}
+
+// header rebuilds the response headers the client reads from a cached entry.
+func (e CacheEntry) header() http.Header {
+	h := http.Header{}
+	if e.Link != "" {
+		h.Set("Link", e.Link)
+	}
+	return h
+}
+
+type cacheFile struct {
+	Version int                   `json:"version"`
+	Entries map[string]CacheEntry `json:"entries"`
+}
+
+// LoadCache reads the cache file at path. A missing file yields an empty
+// cache. An unreadable or corrupt file also yields an empty cache, along with
+// an error describing the problem so the caller can report it.
+func LoadCache(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: make(map[string]CacheEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("reading cache file: %w", err)
+	}
+	var f cacheFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return c, fmt.Errorf("parsing cache file: %w", err)
+	}
+	if f.Version == cacheVersion && f.Entries != nil {
+		c.entries = f.Entries
+	}
+	return c, nil
+}
+
+// lookup returns the cached entry for url, if any.
+func (c *Cache) lookup(url string) (CacheEntry, bool) {
+	if c == nil {
+		return CacheEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[sanitizeRequestURL(url)]
+	return e, ok
+}
+
+// store records a successful response for url. Bodies that are not valid JSON
+// are not cached.
+func (c *Cache) store(url string, e CacheEntry) {
+	if c == nil || !json.Valid(e.Body) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[sanitizeRequestURL(url)] = e
+	c.changed = true
+}
+
+// Save writes the cache file if any entry changed. The file is replaced
+// atomically so an interrupted run cannot leave a truncated cache.
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.changed {
+		return nil
+	}
+	data, err := json.Marshal(cacheFile{Version: cacheVersion, Entries: c.entries})
+	if err != nil {
+		return fmt.Errorf("encoding cache file: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	c.changed = false
+	return nil
+}
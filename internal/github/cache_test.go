@@ -0,0 +1,135 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// etagServer serves two pages of repositories with fixed ETags and answers
+// matching If-None-Match headers with 304.
+func etagServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var seen []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		etag := `"page-` + page + `"`
+		seen = append(seen, fmt.Sprintf("page=%s if-none-match=%s", page, r.Header.Get("If-None-Match")))
+		if page == "1" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/acme/repos?per_page=100&page=2>; rel="next"`, server.URL))
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `[{"name":"repo-%s","clone_url":"https://github.com/acme/repo-%s.git","default_branch":"main"}]`, page, page)
+	}))
+	t.Cleanup(server.Close)
+	return server, &seen
+}
+
+func TestCache_ConditionalRequestsReuseCachedPages(t *testing.T) {
+	server, seen := etagServer(t)
+	path := filepath.Join(t.TempDir(), ".ghorgsync-cache.json")
+
+	cache, err := LoadCache(path)
+	if err != nil {
+		t.Fatalf("LoadCache returned error: %v", err)
+	}
+	client := NewClient(server.URL, "", nil, nil)
+	client.SetCache(cache)
+	if _, err := client.ListOrgRepos("acme"); err != nil {
+		t.Fatalf("first ListOrgRepos returned error: %v", err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	reloaded, err := LoadCache(path)
+	if err != nil {
+		t.Fatalf("LoadCache returned error: %v", err)
+	}
+	client = NewClient(server.URL, "", nil, nil)
+	client.SetCache(reloaded)
+	repos, err := client.ListOrgRepos("acme")
+	if err != nil {
+		t.Fatalf("second ListOrgRepos returned error: %v", err)
+	}
+	if len(repos) != 2 || repos[0].Name != "repo-1" || repos[1].Name != "repo-2" {
+		t.Fatalf("unexpected repos from cache: %+v", repos)
+	}
+
+	want := []string{
+		`page=1 if-none-match=`,
+		`page=2 if-none-match=`,
+		`page=1 if-none-match="page-1"`,
+		`page=2 if-none-match="page-2"`,
+	}
+	if strings.Join(*seen, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected requests:\n%s", strings.Join(*seen, "\n"))
+	}
+}
+
+func TestCache_OfflineServesCachedInventory(t *testing.T) {
+	server, seen := etagServer(t)
+	cache, _ := LoadCache(filepath.Join(t.TempDir(), ".ghorgsync-cache.json"))
+
+	online := NewClient(server.URL, "", nil, nil)
+	online.SetCache(cache)
+	if _, err := online.ListOrgRepos("acme"); err != nil {
+		t.Fatalf("ListOrgRepos returned error: %v", err)
+	}
+	requests := len(*seen)
+
+	offline := NewClient(server.URL, "", nil, nil)
+	offline.SetCache(cache)
+	offline.SetOffline(true)
+	repos, err := offline.ListOrgRepos("acme")
+	if err != nil {
+		t.Fatalf("offline ListOrgRepos returned error: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 cached repos, got %d", len(repos))
+	}
+	if len(*seen) != requests {
+		t.Fatal("offline client contacted the API")
+	}
+
+	if _, err := offline.ListOrgRepos("other"); err == nil || !strings.Contains(err.Error(), "no cached response") {
+		t.Fatalf("expected missing-cache error, got %v", err)
+	}
+}
+
+func TestLoadCache_CorruptFileYieldsEmptyCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ghorgsync-cache.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := LoadCache(path)
+	if err == nil {
+		t.Fatal("expected error for corrupt cache file")
+	}
+	if cache == nil {
+		t.Fatal("expected usable empty cache alongside the error")
+	}
+	if _, ok := cache.lookup("https://api.github.com/orgs/acme/repos"); ok {
+		t.Fatal("expected empty cache")
+	}
+}
+
+func TestCache_SaveWithoutChangesDoesNotWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ghorgsync-cache.json")
+	cache, _ := LoadCache(path)
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no cache file, stat err = %v", err)
+	}
+}
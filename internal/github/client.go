@@ -3,6 +3,7 @@ package github
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,12 +17,42 @@ import (
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
 )
 
+// DefaultAPIURL is the REST API base URL for github.com.
+const DefaultAPIURL = "https://api.github.com"
+
+// errNotFound marks an HTTP 404 response.
+var errNotFound = errors.New("not found")
+
+// TeamNotFoundError reports a teams entry that does not name a team the token
+// can see in the organization.
+type TeamNotFoundError struct {
+	Org  string
+	Slug string
+}
+
+func (e *TeamNotFoundError) Error() string {
+	return fmt.Sprintf("team %q not found in organization %q; teams entries must be team slugs (as in /orgs/%s/teams/<slug>) visible to your token",
+		e.Slug, e.Org, e.Org)
+}
+
 // Client wraps GitHub API access.
 type Client struct {
+	baseURL    string
 	token      string
+	app        *appTokenSource // set by UseApp; replaces token
 	httpClient *http.Client
 	verbosef   func(string, ...any)
 	tracef     func(string, ...any)
+	observe    func(APIRequestRecord)
+
+	// rate-limit handling: total wait budget, and clock hooks for tests
+	rateLimitWait time.Duration
+	sleep         func(time.Duration)
+	now           func() time.Time
+
+	// conditional-request cache; offline serves every request from it
+	cache   *Cache
+	offline bool
 
 	// cached authenticated user (populated lazily by GetAuthenticatedUser)
 	authUserOnce  sync.Once
@@ -30,29 +61,123 @@ type Client struct {
 }
 
 // NewClient creates a new GitHub API client.
-// It resolves a token from GITHUB_TOKEN, GH_TOKEN env vars, or gh CLI auth.
+// baseURL is the REST API root (see ResolveAPIURL); every endpoint is built from it.
 // logf is called for verbose (level-1) messages; tracef for trace (level-2) messages.
-func NewClient(token string, logf func(string, ...any), tracef func(string, ...any)) *Client {
+func NewClient(baseURL, token string, logf func(string, ...any), tracef func(string, ...any)) *Client {
 	return &Client{
-		token: token,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 		verbosef: logf,
 		tracef:   tracef,
+		sleep:    time.Sleep,
+		now:      time.Now,
+	}
+}
+
+// SetCache enables conditional requests: cached responses are revalidated
+// with If-None-Match/If-Modified-Since and reused when GitHub answers 304.
+func (c *Client) SetCache(cache *Cache) {
+	c.cache = cache
+}
+
+// SetOffline serves every request from the cache without contacting the API.
+// Requests with no cached response fail.
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// SetRateLimitWait sets the maximum total time to wait for rate limits to
+// reset before giving up with a *RateLimitError. The default is zero: rate
+// limits fail immediately.
+func (c *Client) SetRateLimitWait(d time.Duration) {
+	c.rateLimitWait = d
+}
+
+// APIRequestRecord describes one completed GitHub API request. It is delivered
+// to the observer registered with Observe, e.g. to write --events-file entries.
+type APIRequestRecord struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`              // sanitized; never includes credentials
+	Status int    `json:"status,omitempty"` // HTTP status, or 0 if no response was received
+	Error  string `json:"error,omitempty"`
+}
+
+// Observe registers fn to receive a record of every completed API request.
+func (c *Client) Observe(fn func(APIRequestRecord)) {
+	c.observe = fn
+}
+
+// observeRequest delivers a completed request to the observer, if any.
+func (c *Client) observeRequest(method, rawURL string, status int, err error) {
+	if c.observe == nil {
+		return
 	}
+	rec := APIRequestRecord{Method: method, URL: sanitizeRequestURL(rawURL), Status: status}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	c.observe(rec)
 }
 
-// ResolveToken finds a GitHub token from environment variables or gh CLI.
-// Priority: GITHUB_TOKEN > GH_TOKEN > gh auth token
-func ResolveToken() string {
-	if t := os.Getenv("GITHUB_TOKEN"); t != "" {
-		return t
+// ResolveAPIURL returns the REST API base URL to use.
+// Priority: GH_HOST env var > configured api_url > DefaultAPIURL.
+// GH_HOST names a host (e.g. ghes.example.com) in the same way as the gh CLI;
+// hosts other than github.com map to the GitHub Enterprise Server path /api/v3.
+func ResolveAPIURL(configured string) string {
+	if host := strings.TrimSpace(os.Getenv("GH_HOST")); host != "" {
+		if strings.EqualFold(host, "github.com") {
+			return DefaultAPIURL
+		}
+		return "https://" + host + "/api/v3"
 	}
-	if t := os.Getenv("GH_TOKEN"); t != "" {
-		return t
+	if configured != "" {
+		return strings.TrimRight(configured, "/")
 	}
-	out, err := exec.Command("gh", "auth", "token").Output()
+	return DefaultAPIURL
+}
+
+// APIHost returns the GitHub host that serves the given API base URL, e.g.
+// "github.com" for https://api.github.com and "ghes.example.com" for
+// https://ghes.example.com/api/v3. The result is used to select credentials.
+func APIHost(apiURL string) string {
+	parsed, err := url.Parse(apiURL)
+	if err != nil || parsed.Host == "" {
+		return "github.com"
+	}
+	host := strings.ToLower(parsed.Host)
+	if host == "api.github.com" || (strings.HasPrefix(host, "api.") && strings.HasSuffix(host, ".ghe.com")) {
+		return strings.TrimPrefix(host, "api.")
+	}
+	return host
+}
+
+// ResolveToken finds a token for the given GitHub host from environment variables or gh CLI.
+// For github.com the priority is GITHUB_TOKEN > GH_TOKEN > gh auth token.
+// For any other host it is GH_ENTERPRISE_TOKEN > GITHUB_ENTERPRISE_TOKEN >
+// gh auth token --hostname <host>, matching the gh CLI so that a github.com
+// token is never sent to an enterprise server.
+func ResolveToken(host string) string {
+	args := []string{"auth", "token"}
+	if host == "" || host == "github.com" {
+		if t := os.Getenv("GITHUB_TOKEN"); t != "" {
+			return t
+		}
+		if t := os.Getenv("GH_TOKEN"); t != "" {
+			return t
+		}
+	} else {
+		if t := os.Getenv("GH_ENTERPRISE_TOKEN"); t != "" {
+			return t
+		}
+		if t := os.Getenv("GITHUB_ENTERPRISE_TOKEN"); t != "" {
+			return t
+		}
+		args = append(args, "--hostname", host)
+	}
+	out, err := exec.Command("gh", args...).Output()
 	if err == nil {
 		t := strings.TrimSpace(string(out))
 		if t != "" {
@@ -64,71 +189,176 @@ func ResolveToken() string {
 
 // ghRepo is the JSON shape returned by the GitHub repos API.
 type ghRepo struct {
-	Name          string `json:"name"`
-	CloneURL      string `json:"clone_url"`
-	DefaultBranch string `json:"default_branch"`
-	Private       bool   `json:"private"`
-	Archived      bool   `json:"archived"`
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	CloneURL      string    `json:"clone_url"`
+	SSHURL        string    `json:"ssh_url"`
+	DefaultBranch string    `json:"default_branch"`
+	Private       bool      `json:"private"`
+	Archived      bool      `json:"archived"`
+	Topics        []string  `json:"topics"`
+	Fork          bool      `json:"fork"`
+	IsTemplate    bool      `json:"is_template"`
+	Language      string    `json:"language"`
+	PushedAt      time.Time `json:"pushed_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Owner         struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// info converts the API shape to a RepoInfo.
+func (r ghRepo) info() model.RepoInfo {
+	return model.RepoInfo{
+		ID:            r.ID,
+		Name:          r.Name,
+		CloneURL:      r.CloneURL,
+		SSHURL:        r.SSHURL,
+		DefaultBranch: r.DefaultBranch,
+		IsPrivate:     r.Private,
+		IsArchived:    r.Archived,
+		Topics:        r.Topics,
+		IsFork:        r.Fork,
+		IsTemplate:    r.IsTemplate,
+		Language:      r.Language,
+		PushedAt:      r.PushedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}
 }
 
 // listRepos fetches all repositories from the given paginated GitHub API URL.
 func (c *Client) listRepos(url string) ([]model.RepoInfo, error) {
-	var repos []model.RepoInfo
+	page, err := c.listRepoPages(url, func(body []byte) ([]ghRepo, error) {
+		var repos []ghRepo
+		err := json.Unmarshal(body, &repos)
+		return repos, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]model.RepoInfo, len(page))
+	for i, r := range page {
+		repos[i] = r.info()
+	}
+	return repos, nil
+}
+
+// listRepoPages fetches every page of a paginated repository listing starting
+// at url. decode extracts the repositories from one page's body.
+func (c *Client) listRepoPages(url string, decode func([]byte) ([]ghRepo, error)) ([]ghRepo, error) {
+	var repos []ghRepo
 
 	for url != "" {
+		bodyBytes, header, err := c.get(url, "repos")
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := decode(bodyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+		repos = append(repos, page...)
+
+		url = nextLink(header.Get("Link"))
+		if url != "" {
+			c.verbosefSafe("api pagination: next=%s", sanitizeRequestURL(url))
+		}
+	}
+
+	return repos, nil
+}
+
+// get performs an authenticated GET request and returns the body and headers
+// of a successful response. Rate-limited responses are retried once the limit
+// resets, provided the total wait stays within the rate-limit budget and the
+// retry limit; what
+// names the resource in transport errors. With a cache, the request is
+// conditional and a 304 response is answered from the cache.
+func (c *Client) get(url, what string) ([]byte, http.Header, error) {
+	cached, haveCached := c.cache.lookup(url)
+	if c.offline {
+		if !haveCached {
+			return nil, nil, fmt.Errorf("offline: no cached response for %s; run once without --offline to populate the cache", sanitizeRequestURL(url))
+		}
+		c.verbosefSafe("api cache: offline, using cached %s", sanitizeRequestURL(url))
+		return cached.Body, cached.header(), nil
+	}
+
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		token, err := c.Token()
+		if err != nil {
+			return nil, nil, err
+		}
 		req, err := http.NewRequest("GET", url, nil)
 		if err != nil {
-			return nil, fmt.Errorf("creating request: %w", err)
+			return nil, nil, fmt.Errorf("creating request: %w", err)
 		}
 		req.Header.Set("Accept", "application/vnd.github+json")
-		if c.token != "" {
-			req.Header.Set("Authorization", "Bearer "+c.token)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
 		}
-		c.verbosefSafe("api request: %s %s headers={Accept:%q Authorization:%t}",
-			req.Method, sanitizeRequestURL(url), req.Header.Get("Accept"), c.token != "")
+		if haveCached {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+		c.verbosefSafe("api request: %s %s headers={Accept:%q Authorization:%t Conditional:%t}",
+			req.Method, sanitizeRequestURL(url), req.Header.Get("Accept"), token != "", haveCached)
 
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
-			return nil, fmt.Errorf("requesting repos: %w", err)
+			c.observeRequest(req.Method, url, 0, err)
+			return nil, nil, fmt.Errorf("requesting %s: %w", what, err)
 		}
+		c.observeRequest(req.Method, url, resp.StatusCode, nil)
 		c.verbosefSafe("api response: %s %s status=%d", req.Method, sanitizeRequestURL(url), resp.StatusCode)
+		c.logRateLimit(resp.Header)
 
 		bodyBytes, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		if err != nil {
-			return nil, fmt.Errorf("reading response body: %w", err)
+			return nil, nil, fmt.Errorf("reading response body: %w", err)
 		}
 		c.tracefSafe("api body: %s", bytes.TrimSpace(bodyBytes))
 
-		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-			return nil, fmt.Errorf("GitHub API auth error (HTTP %d): check your token", resp.StatusCode)
+		if wait, limited := rateLimitDelay(resp.StatusCode, resp.Header, bodyBytes, c.now(), attempt); limited {
+			if attempt >= maxRateLimitRetries {
+				return nil, nil, &RateLimitError{StatusCode: resp.StatusCode, Reset: c.now().Add(wait), MaxWait: c.rateLimitWait, Retries: attempt}
+			}
+			if waited+wait > c.rateLimitWait {
+				return nil, nil, &RateLimitError{StatusCode: resp.StatusCode, Reset: c.now().Add(wait), MaxWait: c.rateLimitWait}
+			}
+			c.verbosefSafe("api rate limit: HTTP %d, waiting %s before retrying", resp.StatusCode, wait)
+			c.sleep(wait)
+			waited += wait
+			continue
 		}
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			return nil, fmt.Errorf("GitHub API error (HTTP %d)", resp.StatusCode)
+		if resp.StatusCode == http.StatusNotModified && haveCached {
+			c.verbosefSafe("api cache: not modified, using cached %s", sanitizeRequestURL(url))
+			return cached.Body, cached.header(), nil
 		}
-
-		var page []ghRepo
-		if err := json.Unmarshal(bodyBytes, &page); err != nil {
-			return nil, fmt.Errorf("decoding response: %w", err)
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return nil, nil, fmt.Errorf("GitHub API auth error (HTTP %d): check your token", resp.StatusCode)
 		}
-
-		for _, r := range page {
-			repos = append(repos, model.RepoInfo{
-				Name:          r.Name,
-				CloneURL:      r.CloneURL,
-				DefaultBranch: r.DefaultBranch,
-				IsPrivate:     r.Private,
-				IsArchived:    r.Archived,
-			})
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil, fmt.Errorf("GitHub API error (HTTP %d): %w", resp.StatusCode, errNotFound)
 		}
-
-		url = nextLink(resp.Header.Get("Link"))
-		if url != "" {
-			c.verbosefSafe("api pagination: next=%s", sanitizeRequestURL(url))
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, nil, fmt.Errorf("GitHub API error (HTTP %d)", resp.StatusCode)
 		}
+		c.cache.store(url, CacheEntry{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Link:         resp.Header.Get("Link"),
+			Body:         bodyBytes,
+		})
+		return bodyBytes, resp.Header, nil
 	}
-
-	return repos, nil
 }
 
 func (c *Client) verbosefSafe(format string, args ...any) {
@@ -170,14 +400,25 @@ func sanitizeRequestURL(rawURL string) string {
 
 // ListOrgRepos lists all repositories for the given organisation.
 func (c *Client) ListOrgRepos(org string) ([]model.RepoInfo, error) {
-	url := fmt.Sprintf("https://api.github.com/orgs/%s/repos?per_page=100&page=1", org)
+	url := fmt.Sprintf("%s/orgs/%s/repos?per_page=100&page=1", c.baseURL, org)
 	return c.listRepos(url)
 }
 
+// ListTeamRepos lists all repositories the given team in the organisation has
+// access to. It returns a *TeamNotFoundError when the team does not exist.
+func (c *Client) ListTeamRepos(org, slug string) ([]model.RepoInfo, error) {
+	url := fmt.Sprintf("%s/orgs/%s/teams/%s/repos?per_page=100&page=1", c.baseURL, org, slug)
+	repos, err := c.listRepos(url)
+	if errors.Is(err, errNotFound) {
+		return nil, &TeamNotFoundError{Org: org, Slug: slug}
+	}
+	return repos, err
+}
+
 // ListUserRepos lists all public repositories for the given user account.
 // Use ListOwnRepos to fetch all repositories (including private) for the authenticated user.
 func (c *Client) ListUserRepos(username string) ([]model.RepoInfo, error) {
-	url := fmt.Sprintf("https://api.github.com/users/%s/repos?per_page=100&page=1", username)
+	url := fmt.Sprintf("%s/users/%s/repos?per_page=100&page=1", c.baseURL, username)
 	return c.listRepos(url)
 }
 
@@ -190,40 +431,9 @@ type ghUser struct {
 // The result is cached after the first call. Safe for concurrent use.
 func (c *Client) GetAuthenticatedUser() (string, error) {
 	c.authUserOnce.Do(func() {
-		const apiURL = "https://api.github.com/user"
-		req, err := http.NewRequest("GET", apiURL, nil)
+		bodyBytes, _, err := c.get(c.baseURL+"/user", "user")
 		if err != nil {
-			c.authUserErr = fmt.Errorf("creating request: %w", err)
-			return
-		}
-		req.Header.Set("Accept", "application/vnd.github+json")
-		if c.token != "" {
-			req.Header.Set("Authorization", "Bearer "+c.token)
-		}
-		c.verbosefSafe("api request: %s %s headers={Accept:%q Authorization:%t}",
-			req.Method, apiURL, req.Header.Get("Accept"), c.token != "")
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			c.authUserErr = fmt.Errorf("requesting user: %w", err)
-			return
-		}
-		c.verbosefSafe("api response: %s %s status=%d", req.Method, apiURL, resp.StatusCode)
-
-		bodyBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			c.authUserErr = fmt.Errorf("reading response body: %w", err)
-			return
-		}
-		c.tracefSafe("api body: %s", bytes.TrimSpace(bodyBytes))
-
-		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-			c.authUserErr = fmt.Errorf("GitHub API auth error (HTTP %d): check your token", resp.StatusCode)
-			return
-		}
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			c.authUserErr = fmt.Errorf("GitHub API error (HTTP %d)", resp.StatusCode)
+			c.authUserErr = err
 			return
 		}
 
@@ -240,7 +450,25 @@ func (c *Client) GetAuthenticatedUser() (string, error) {
 
 // ListOwnRepos lists all repositories (public and private) for the authenticated user.
 func (c *Client) ListOwnRepos() ([]model.RepoInfo, error) {
-	return c.listRepos("https://api.github.com/user/repos?per_page=100&page=1")
+	return c.listRepos(c.baseURL + "/user/repos?per_page=100&page=1")
+}
+
+// RepoGone reports whether owner/name was deleted or transferred: the API
+// answers 404, or follows a redirect to a repository under another owner. A
+// repository that was only renamed within the owner is not gone.
+func (c *Client) RepoGone(owner, name string) (bool, error) {
+	bodyBytes, _, err := c.get(fmt.Sprintf("%s/repos/%s/%s", c.baseURL, owner, name), "repository")
+	if errors.Is(err, errNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var repo ghRepo
+	if err := json.Unmarshal(bodyBytes, &repo); err != nil {
+		return false, fmt.Errorf("decoding response: %w", err)
+	}
+	return !strings.EqualFold(repo.Owner.Login, owner), nil
 }
 
 // nextLink parses the GitHub Link header and returns the URL for rel="next", or "".
@@ -1,12 +1,13 @@
 package github
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestListRepos_VerboseLogsRequestsAndResponses(t *testing.T) {
@@ -20,7 +21,7 @@ func TestListRepos_VerboseLogsRequestsAndResponses(t *testing.T) {
 	defer server.Close()
 
 	var logs []string
-	client := NewClient("super-secret-token",
+	client := NewClient(DefaultAPIURL, "super-secret-token",
 		func(format string, args ...any) {
 			logs = append(logs, fmt.Sprintf(format, args...))
 		},
@@ -66,7 +67,7 @@ func TestListRepos_TraceLogsResponseBody(t *testing.T) {
 	defer server.Close()
 
 	var traceLogs []string
-	client := NewClient("token",
+	client := NewClient(DefaultAPIURL, "token",
 		func(format string, args ...any) {}, // level-1 logger (discarded)
 		func(format string, args ...any) {
 			traceLogs = append(traceLogs, fmt.Sprintf(format, args...))
@@ -100,7 +101,7 @@ func TestListRepos_NoTraceLogger_BodyNotLogged(t *testing.T) {
 
 	var logs []string
 	// Pass nil tracef — body must not appear in level-1 logs
-	client := NewClient("token",
+	client := NewClient(DefaultAPIURL, "token",
 		func(format string, args ...any) {
 			logs = append(logs, fmt.Sprintf(format, args...))
 		},
@@ -143,8 +144,7 @@ func TestGetAuthenticatedUser_ReturnsLogin(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient("test-token", nil, nil)
-	client.httpClient = &http.Client{Transport: rewriteHostTransport{target: server.URL}}
+	client := NewClient(server.URL, "test-token", nil, nil)
 
 	login, err := client.GetAuthenticatedUser()
 	if err != nil {
@@ -164,8 +164,7 @@ func TestGetAuthenticatedUser_CachesResult(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient("token", nil, nil)
-	client.httpClient = &http.Client{Transport: rewriteHostTransport{target: server.URL}}
+	client := NewClient(server.URL, "token", nil, nil)
 
 	for i := 0; i < 3; i++ {
 		login, err := client.GetAuthenticatedUser()
@@ -188,8 +187,7 @@ func TestGetAuthenticatedUser_AuthError(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient("bad-token", nil, nil)
-	client.httpClient = &http.Client{Transport: rewriteHostTransport{target: server.URL}}
+	client := NewClient(server.URL, "bad-token", nil, nil)
 
 	_, err := client.GetAuthenticatedUser()
 	if err == nil {
@@ -206,12 +204,11 @@ func TestListOwnRepos_ReturnsAllRepos(t *testing.T) {
 			t.Fatalf("unexpected path: %s", r.URL.Path)
 		}
 		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintln(w, `[{"name":"private-repo","clone_url":"https://github.com/octocat/private-repo.git","default_branch":"main","private":true,"archived":false}]`)
+		fmt.Fprintln(w, `[{"name":"private-repo","clone_url":"https://github.com/octocat/private-repo.git","default_branch":"main","private":true,"archived":false,"topics":["go","team-payments"],"fork":true,"is_template":false,"language":"Go","pushed_at":"2024-01-02T03:04:05Z","updated_at":null}]`)
 	}))
 	defer server.Close()
 
-	client := NewClient("token", nil, nil)
-	client.httpClient = &http.Client{Transport: rewriteHostTransport{target: server.URL}}
+	client := NewClient(server.URL, "token", nil, nil)
 
 	repos, err := client.ListOwnRepos()
 	if err != nil {
@@ -226,22 +223,187 @@ func TestListOwnRepos_ReturnsAllRepos(t *testing.T) {
 	if !repos[0].IsPrivate {
 		t.Fatal("expected repo to be private")
 	}
+	if len(repos[0].Topics) != 2 || repos[0].Topics[1] != "team-payments" {
+		t.Fatalf("unexpected topics: %v", repos[0].Topics)
+	}
+	if !repos[0].IsFork || repos[0].IsTemplate || repos[0].Language != "Go" {
+		t.Fatalf("unexpected fork/template/language: %+v", repos[0])
+	}
+	if got := repos[0].PushedAt.Format(time.RFC3339); got != "2024-01-02T03:04:05Z" || !repos[0].UpdatedAt.IsZero() {
+		t.Fatalf("unexpected pushed_at/updated_at: %v / %v", repos[0].PushedAt, repos[0].UpdatedAt)
+	}
+}
+
+func TestListTeamRepos_Paginates(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/acme/teams/payments/repos" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/acme/teams/payments/repos?per_page=100&page=2>; rel="next"`, server.URL))
+			fmt.Fprintln(w, `[{"name":"billing"}]`)
+			return
+		}
+		fmt.Fprintln(w, `[{"name":"ledger"}]`)
+	}))
+	defer server.Close()
+
+	repos, err := NewClient(server.URL, "token", nil, nil).ListTeamRepos("acme", "payments")
+	if err != nil {
+		t.Fatalf("ListTeamRepos returned error: %v", err)
+	}
+	if len(repos) != 2 || repos[0].Name != "billing" || repos[1].Name != "ledger" {
+		t.Fatalf("unexpected team repos: %+v", repos)
+	}
+}
+
+func TestListTeamRepos_UnknownTeam(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "token", nil, nil).ListTeamRepos("acme", "no-such-team")
+	var teamErr *TeamNotFoundError
+	if !errors.As(err, &teamErr) || teamErr.Slug != "no-such-team" {
+		t.Fatalf("expected TeamNotFoundError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `team "no-such-team" not found in organization "acme"`) {
+		t.Errorf("unexpected message: %v", err)
+	}
 }
 
-// rewriteHostTransport redirects all HTTP requests to a fixed target base URL.
-// This lets tests exercise methods that use hardcoded API URLs (e.g. https://api.github.com/user).
-type rewriteHostTransport struct {
-	target string // e.g. "http://127.0.0.1:PORT"
+func TestRepoGone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/deleted":
+			http.NotFound(w, r)
+		case "/repos/acme/moved":
+			http.Redirect(w, r, "/repositories/7", http.StatusMovedPermanently)
+		case "/repositories/7":
+			fmt.Fprintln(w, `{"id":7,"name":"moved","owner":{"login":"globex"}}`)
+		case "/repos/acme/old-name":
+			http.Redirect(w, r, "/repositories/8", http.StatusMovedPermanently)
+		case "/repositories/8":
+			fmt.Fprintln(w, `{"id":8,"name":"new-name","owner":{"login":"Acme"}}`)
+		case "/repos/acme/private":
+			fmt.Fprintln(w, `{"id":9,"name":"private","private":true,"owner":{"login":"acme"}}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "token", nil, nil)
+
+	for name, want := range map[string]bool{"deleted": true, "moved": true, "old-name": false, "private": false} {
+		gone, err := client.RepoGone("acme", name)
+		if err != nil || gone != want {
+			t.Errorf("RepoGone(acme/%s) = %t, %v; want %t", name, gone, err, want)
+		}
+	}
+	if _, err := client.RepoGone("acme", "broken"); err == nil {
+		t.Error("expected an error for a server failure")
+	}
 }
 
-func (t rewriteHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	parsed, err := url.Parse(t.target)
+func TestListOrgRepos_UsesConfiguredBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/orgs/acme/repos" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `[{"name":"enterprise-repo","clone_url":"https://ghes.example.com/acme/enterprise-repo.git","default_branch":"main"}]`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/api/v3/", "token", nil, nil)
+
+	repos, err := client.ListOrgRepos("acme")
 	if err != nil {
-		return nil, fmt.Errorf("rewriteHostTransport: invalid target %q: %w", t.target, err)
+		t.Fatalf("ListOrgRepos returned error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].Name != "enterprise-repo" {
+		t.Fatalf("unexpected repos: %+v", repos)
+	}
+}
+
+func TestResolveAPIURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		ghHost     string
+		configured string
+		want       string
+	}{
+		{name: "default", want: DefaultAPIURL},
+		{name: "configured", configured: "https://ghes.example.com/api/v3/", want: "https://ghes.example.com/api/v3"},
+		{name: "gh host overrides config", ghHost: "other.example.com", configured: "https://ghes.example.com/api/v3", want: "https://other.example.com/api/v3"},
+		{name: "gh host github.com", ghHost: "github.com", configured: "https://ghes.example.com/api/v3", want: DefaultAPIURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GH_HOST", tt.ghHost)
+			if got := ResolveAPIURL(tt.configured); got != tt.want {
+				t.Errorf("ResolveAPIURL(%q) = %q, want %q", tt.configured, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIHost(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{DefaultAPIURL, "github.com"},
+		{"https://ghes.example.com/api/v3", "ghes.example.com"},
+		{"https://api.acme.ghe.com", "acme.ghe.com"},
+		{"http://127.0.0.1:8080", "127.0.0.1:8080"},
+	}
+	for _, tt := range tests {
+		if got := APIHost(tt.input); got != tt.want {
+			t.Errorf("APIHost(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResolveToken_EnterpriseHostIgnoresGitHubToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "dotcom-token")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise-token")
+	t.Setenv("GITHUB_ENTERPRISE_TOKEN", "")
+
+	if got := ResolveToken("ghes.example.com"); got != "enterprise-token" {
+		t.Errorf("ResolveToken(enterprise) = %q, want %q", got, "enterprise-token")
+	}
+	if got := ResolveToken("github.com"); got != "dotcom-token" {
+		t.Errorf("ResolveToken(github.com) = %q, want %q", got, "dotcom-token")
+	}
+}
+
+func TestListRepos_ObserverReceivesRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `[]`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", nil, nil)
+	var records []APIRequestRecord
+	client.Observe(func(rec APIRequestRecord) { records = append(records, rec) })
+
+	if _, err := client.listRepos(server.URL + "/orgs/acme/repos?access_token=secret"); err != nil {
+		t.Fatalf("listRepos returned error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	rec := records[0]
+	if rec.Method != "GET" || rec.Status != 200 || rec.Error != "" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if strings.Contains(rec.URL, "secret") {
+		t.Fatalf("credential leaked in record URL: %s", rec.URL)
 	}
-	cloned := req.Clone(req.Context())
-	cloned.URL.Scheme = parsed.Scheme
-	cloned.URL.Host = parsed.Host
-	cloned.Host = parsed.Host
-	return http.DefaultTransport.RoundTrip(cloned)
 }
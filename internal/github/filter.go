@@ -1,12 +1,23 @@
 package github
 
 import (
+	"time"
+
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/config"
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
 )
 
-// FilterRepos applies visibility, archived, and exclusion filters to the repo list.
+// FilterRepos applies visibility, archived, fork, template, allowlist,
+// exclusion, topic, language, and activity filters to the repo list, in that
+// order. With keep_stale_clones, repos outside active_within are included but
+// marked Stale so they are synced only if already cloned. Repos removed by any filter other
+// than visibility are returned in excluded so local copies can be reported as
+// excluded-but-present.
 func FilterRepos(repos []model.RepoInfo, cfg *config.Config) (included []model.RepoInfo, excluded []string) {
+	var activeSince time.Time
+	if window := cfg.ActivityWindow(); window > 0 {
+		activeSince = time.Now().Add(-window)
+	}
 	for _, r := range repos {
 		// Visibility filter
 		if r.IsPrivate && !cfg.ShouldIncludePrivate() {
@@ -20,12 +31,71 @@ func FilterRepos(repos []model.RepoInfo, cfg *config.Config) (included []model.R
 			excluded = append(excluded, r.Name)
 			continue
 		}
-		// Exclusion filter
+		// Fork and template filters
+		if r.IsFork && !cfg.ShouldIncludeForks() {
+			excluded = append(excluded, r.Name)
+			continue
+		}
+		if r.IsTemplate && !cfg.ShouldIncludeTemplates() {
+			excluded = append(excluded, r.Name)
+			continue
+		}
+		// Allowlist filter: when include_repos is set, only listed repos remain
+		if !cfg.IsIncluded(r.Name) {
+			excluded = append(excluded, r.Name)
+			continue
+		}
+		// Exclusion filter: exclusions win over the allowlist
 		if cfg.IsExcluded(r.Name) {
 			excluded = append(excluded, r.Name)
 			continue
 		}
+		// Topic filters
+		if !cfg.IsIncludedByTopics(r.Topics) || cfg.IsExcludedByTopics(r.Topics) {
+			excluded = append(excluded, r.Name)
+			continue
+		}
+		// Language filters
+		if !cfg.IsIncludedByLanguage(r.Language) {
+			excluded = append(excluded, r.Name)
+			continue
+		}
+		// Activity filter
+		if !activeSince.IsZero() && r.LastActivity().Before(activeSince) {
+			if !cfg.ShouldKeepStaleClones() {
+				excluded = append(excluded, r.Name)
+				continue
+			}
+			r.Stale = true
+		}
 		included = append(included, r)
 	}
 	return
 }
+
+// RestrictToTeams keeps the repos in the org listing that belong to the given
+// teams' repo listings: those of any team, or with matchAll those of every
+// team. The org listing is kept as the source of repo metadata and order.
+func RestrictToTeams(repos []model.RepoInfo, teamRepos [][]model.RepoInfo, matchAll bool) []model.RepoInfo {
+	counts := make(map[string]int)
+	for _, team := range teamRepos {
+		seen := make(map[string]bool)
+		for _, r := range team {
+			if !seen[r.Name] {
+				seen[r.Name] = true
+				counts[r.Name]++
+			}
+		}
+	}
+	need := 1
+	if matchAll {
+		need = max(len(teamRepos), 1)
+	}
+	var kept []model.RepoInfo
+	for _, r := range repos {
+		if counts[r.Name] >= need {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -2,6 +2,7 @@ package github
 
 import (
 	"testing"
+	"time"
 
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/config"
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
@@ -156,3 +157,203 @@ func TestFilterRepos_ArchivedIncludedWhenConfigured(t *testing.T) {
 		t.Errorf("expected 0 excluded repos, got %d", len(excluded))
 	}
 }
+
+func sampleReposWithTopics() []model.RepoInfo {
+	return []model.RepoInfo{
+		{Name: "payments-api", Topics: []string{"team-payments", "go"}},
+		{Name: "payments-old", Topics: []string{"team-payments", "deprecated"}},
+		{Name: "search", Topics: []string{"team-search", "go"}},
+		{Name: "untagged"},
+	}
+}
+
+func TestFilterRepos_IncludeTopicsAny(t *testing.T) {
+	cfg := &config.Config{Organization: "org", IncludeTopics: []string{"team-payments", "team-search"}}
+	included, excluded := FilterRepos(sampleReposWithTopics(), cfg)
+	if len(included) != 3 {
+		t.Errorf("expected 3 included repos, got %d", len(included))
+	}
+	if len(excluded) != 1 || excluded[0] != "untagged" {
+		t.Errorf("expected untagged to be excluded, got %v", excluded)
+	}
+}
+
+func TestFilterRepos_IncludeTopicsAll(t *testing.T) {
+	cfg := &config.Config{Organization: "org", IncludeTopics: []string{"team-payments", "go"}, TopicMatch: "all"}
+	included, excluded := FilterRepos(sampleReposWithTopics(), cfg)
+	if len(included) != 1 || included[0].Name != "payments-api" {
+		t.Errorf("expected only payments-api, got %v", included)
+	}
+	if len(excluded) != 3 {
+		t.Errorf("expected 3 excluded repos, got %v", excluded)
+	}
+}
+
+func TestFilterRepos_ExcludeTopics(t *testing.T) {
+	cfg := &config.Config{
+		Organization:  "org",
+		IncludeTopics: []string{"team-payments"},
+		ExcludeTopics: []string{"Deprecated"},
+	}
+	included, excluded := FilterRepos(sampleReposWithTopics(), cfg)
+	if len(included) != 1 || included[0].Name != "payments-api" {
+		t.Errorf("expected only payments-api, got %v", included)
+	}
+	expectedExcluded := map[string]bool{"payments-old": true, "search": true, "untagged": true}
+	if len(excluded) != len(expectedExcluded) {
+		t.Errorf("expected %d excluded repos, got %v", len(expectedExcluded), excluded)
+	}
+	for _, name := range excluded {
+		if !expectedExcluded[name] {
+			t.Errorf("unexpected excluded repo: %q", name)
+		}
+	}
+}
+
+func TestFilterRepos_IncludeReposPrecedence(t *testing.T) {
+	cfg := &config.Config{
+		Organization:   "org",
+		IncludePublic:  new(false),
+		IncludePrivate: new(true),
+		IncludeRepos:   []string{"^private-repo$", "^secret-", "^archived-", "^public-repo$"},
+		ExcludeRepos:   []string{"^secret-project$"},
+	}
+	repos := append(sampleRepos(), model.RepoInfo{Name: "archived-private", IsPrivate: true, IsArchived: true})
+	included, excluded := FilterRepos(repos, cfg)
+
+	// Visibility applies before the allowlist, archived repos still need
+	// include_archived, and exclusions win over the allowlist.
+	if len(included) != 1 || included[0].Name != "private-repo" {
+		t.Errorf("expected only private-repo to be included, got %v", included)
+	}
+	expectedExcluded := map[string]bool{"secret-project": true, "archived-private": true}
+	if len(excluded) != len(expectedExcluded) {
+		t.Errorf("expected %d excluded repos, got %v", len(expectedExcluded), excluded)
+	}
+	for _, name := range excluded {
+		if !expectedExcluded[name] {
+			t.Errorf("unexpected excluded repo: %q", name)
+		}
+	}
+}
+
+func TestFilterRepos_OutsideAllowlistIsExcluded(t *testing.T) {
+	cfg := &config.Config{Organization: "org", IncludeRepos: []string{"^public-repo$"}}
+	included, excluded := FilterRepos(sampleRepos(), cfg)
+	if len(included) != 1 || included[0].Name != "public-repo" {
+		t.Errorf("expected only public-repo, got %v", included)
+	}
+	if len(excluded) != 3 {
+		t.Errorf("expected the other 3 repos to be excluded, got %v", excluded)
+	}
+}
+
+func sampleReposWithKinds() []model.RepoInfo {
+	return []model.RepoInfo{
+		{Name: "service", Language: "Go"},
+		{Name: "infra", Language: "HCL"},
+		{Name: "patched-lib", Language: "Go", IsFork: true},
+		{Name: "go-template", Language: "Go", IsTemplate: true},
+		{Name: "web", Language: "TypeScript"},
+		{Name: "docs"},
+	}
+}
+
+func TestFilterRepos_ForksAndTemplatesIncludedByDefault(t *testing.T) {
+	cfg := &config.Config{Organization: "org"}
+	included, _ := FilterRepos(sampleReposWithKinds(), cfg)
+	if len(included) != 6 {
+		t.Errorf("expected all 6 repos, got %d", len(included))
+	}
+}
+
+func TestFilterRepos_ExcludeForksAndTemplates(t *testing.T) {
+	cfg := &config.Config{Organization: "org", IncludeForks: new(false), IncludeTemplates: new(false)}
+	included, excluded := FilterRepos(sampleReposWithKinds(), cfg)
+	if len(included) != 4 {
+		t.Errorf("expected 4 included repos, got %v", included)
+	}
+	expectedExcluded := map[string]bool{"patched-lib": true, "go-template": true}
+	if len(excluded) != len(expectedExcluded) {
+		t.Errorf("expected %d excluded repos, got %v", len(expectedExcluded), excluded)
+	}
+	for _, name := range excluded {
+		if !expectedExcluded[name] {
+			t.Errorf("unexpected excluded repo: %q", name)
+		}
+	}
+}
+
+func TestFilterRepos_Languages(t *testing.T) {
+	cfg := &config.Config{Organization: "org", Languages: []string{"go", "HCL"}, IncludeForks: new(false)}
+	included, excluded := FilterRepos(sampleReposWithKinds(), cfg)
+	names := make(map[string]bool)
+	for _, r := range included {
+		names[r.Name] = true
+	}
+	if len(included) != 3 || !names["service"] || !names["infra"] || !names["go-template"] {
+		t.Errorf("expected service, infra, and go-template, got %v", included)
+	}
+	if len(excluded) != 3 {
+		t.Errorf("expected fork, web, and docs to be excluded, got %v", excluded)
+	}
+}
+
+func TestFilterRepos_ExcludeLanguages(t *testing.T) {
+	cfg := &config.Config{Organization: "org", ExcludeLanguages: []string{"typescript"}}
+	_, excluded := FilterRepos(sampleReposWithKinds(), cfg)
+	if len(excluded) != 1 || excluded[0] != "web" {
+		t.Errorf("expected only web to be excluded, got %v", excluded)
+	}
+}
+
+func sampleReposWithActivity() []model.RepoInfo {
+	now := time.Now()
+	return []model.RepoInfo{
+		{Name: "active", PushedAt: now.Add(-24 * time.Hour)},
+		{Name: "stale", PushedAt: now.Add(-400 * 24 * time.Hour), UpdatedAt: now},
+		{Name: "never-pushed", UpdatedAt: now.Add(-2 * time.Hour)},
+	}
+}
+
+func TestFilterRepos_ActiveWithinExcludesStale(t *testing.T) {
+	cfg := &config.Config{Organization: "org", ActiveWithin: "180d"}
+	included, excluded := FilterRepos(sampleReposWithActivity(), cfg)
+	if len(included) != 2 {
+		t.Errorf("expected active and never-pushed to be included, got %v", included)
+	}
+	if len(excluded) != 1 || excluded[0] != "stale" {
+		t.Errorf("expected stale to be excluded, got %v", excluded)
+	}
+}
+
+func TestFilterRepos_KeepStaleClonesMarksStale(t *testing.T) {
+	cfg := &config.Config{Organization: "org", ActiveWithin: "180d", KeepStaleClones: new(true)}
+	included, excluded := FilterRepos(sampleReposWithActivity(), cfg)
+	if len(included) != 3 || len(excluded) != 0 {
+		t.Fatalf("expected all repos included, got %v / %v", included, excluded)
+	}
+	for _, r := range included {
+		if r.Stale != (r.Name == "stale") {
+			t.Errorf("%s: Stale = %t", r.Name, r.Stale)
+		}
+	}
+}
+
+func TestRestrictToTeams(t *testing.T) {
+	org := []model.RepoInfo{{Name: "billing", Language: "Go"}, {Name: "ledger"}, {Name: "search"}, {Name: "web"}}
+	teams := [][]model.RepoInfo{
+		{{Name: "billing"}, {Name: "ledger"}},
+		{{Name: "ledger"}, {Name: "search"}, {Name: "other-org-repo"}},
+	}
+
+	anyTeam := RestrictToTeams(org, teams, false)
+	if len(anyTeam) != 3 || anyTeam[0].Name != "billing" || anyTeam[0].Language != "Go" {
+		t.Errorf("expected billing, ledger, and search with org metadata, got %+v", anyTeam)
+	}
+
+	allTeams := RestrictToTeams(org, teams, true)
+	if len(allTeams) != 1 || allTeams[0].Name != "ledger" {
+		t.Errorf("expected only ledger, got %+v", allTeams)
+	}
+}
@@ -0,0 +1,88 @@
+package github
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secondaryRateLimitBackoff is the first retry delay for a secondary rate limit
+// that does not say how long to wait. GitHub asks clients to wait at least a
+// minute; the delay doubles on each further attempt.
+const secondaryRateLimitBackoff = time.Minute
+
+// minRateLimitDelay is the shortest wait before retrying a rate-limited
+// request, so a Retry-After of 0 or a date in the past still spends budget.
+const minRateLimitDelay = time.Second
+
+// maxRateLimitRetries bounds how often one request is retried after a rate
+// limit, however short the waits.
+const maxRateLimitRetries = 10
+
+// RateLimitError reports a GitHub API rate limit that did not lift within the
+// configured maximum wait.
+type RateLimitError struct {
+	StatusCode int
+	Reset      time.Time     // when GitHub expects the limit to lift
+	MaxWait    time.Duration // total wait budget that would have been exceeded
+	Retries    int           // set when the retry limit was reached instead
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Retries > 0 {
+		return fmt.Sprintf("GitHub API rate limit exceeded (HTTP %d): still limited after %d retries", e.StatusCode, e.Retries)
+	}
+	return fmt.Sprintf("GitHub API rate limit exceeded (HTTP %d): resets at %s, beyond rate_limit_max_wait of %s",
+		e.StatusCode, e.Reset.Local().Format("15:04:05"), e.MaxWait)
+}
+
+// rateLimitDelay reports whether a response is a rate limit rather than an
+// authentication failure and, if so, how long to wait before retrying.
+// attempt is the zero-based number of rate-limited attempts so far and drives
+// the backoff when the response carries no timing headers.
+// This is a pure function for testability.
+func rateLimitDelay(status int, header http.Header, body []byte, now time.Time, attempt int) (time.Duration, bool) {
+	if status != http.StatusForbidden && status != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	// Secondary rate limits send Retry-After in seconds (or, rarely, a date).
+	if retryAfter := strings.TrimSpace(header.Get("Retry-After")); retryAfter != "" {
+		if secs, err := strconv.Atoi(retryAfter); err == nil && secs >= 0 {
+			return max(time.Duration(secs)*time.Second, minRateLimitDelay), true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return max(at.Sub(now), minRateLimitDelay), true
+		}
+	}
+
+	// Primary rate limits exhaust the quota and say when it resets.
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// Allow a second of clock skew so the retry lands after the reset.
+			return max(time.Unix(reset, 0).Sub(now), 0) + time.Second, true
+		}
+		return secondaryRateLimitBackoff << attempt, true
+	}
+
+	if status == http.StatusTooManyRequests || bytes.Contains(bytes.ToLower(body), []byte("rate limit")) {
+		return secondaryRateLimitBackoff << attempt, true
+	}
+	return 0, false
+}
+
+// logRateLimit reports the remaining request quota in verbose output.
+func (c *Client) logRateLimit(header http.Header) {
+	remaining := header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return
+	}
+	reset := header.Get("X-RateLimit-Reset")
+	if epoch, err := strconv.ParseInt(reset, 10, 64); err == nil {
+		reset = time.Unix(epoch, 0).Local().Format(time.RFC3339)
+	}
+	c.verbosefSafe("api rate limit: remaining=%s limit=%s reset=%s", remaining, header.Get("X-RateLimit-Limit"), reset)
+}
@@ -0,0 +1,176 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitDelay(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name    string
+		status  int
+		header  map[string]string
+		body    string
+		attempt int
+		want    time.Duration
+		limited bool
+	}{
+		{name: "success", status: 200, header: map[string]string{"X-RateLimit-Remaining": "0"}},
+		{name: "auth failure", status: 403, header: map[string]string{"X-RateLimit-Remaining": "4999"}, body: `{"message":"Resource not accessible"}`},
+		{name: "unauthorized", status: 401, body: `{"message":"Bad credentials"}`},
+		{name: "primary limit", status: 403, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000030"}, want: 31 * time.Second, limited: true},
+		{name: "primary limit already reset", status: 403, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1699999990"}, want: time.Second, limited: true},
+		{name: "retry after seconds", status: 403, header: map[string]string{"Retry-After": "45"}, want: 45 * time.Second, limited: true},
+		{name: "retry after zero", status: 429, header: map[string]string{"Retry-After": "0"}, want: time.Second, limited: true},
+		{name: "retry after date in the past", status: 403, header: map[string]string{"Retry-After": "Tue, 14 Nov 2023 22:00:00 GMT"}, want: time.Second, limited: true},
+		{name: "retry after wins over reset", status: 429, header: map[string]string{"Retry-After": "5", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000300"}, want: 5 * time.Second, limited: true},
+		{name: "secondary limit by message", status: 403, body: `{"message":"You have exceeded a secondary rate limit."}`, attempt: 1, want: 2 * time.Minute, limited: true},
+		{name: "too many requests without headers", status: 429, want: time.Minute, limited: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			got, limited := rateLimitDelay(tt.status, header, []byte(tt.body), now, tt.attempt)
+			if limited != tt.limited || got != tt.want {
+				t.Errorf("rateLimitDelay() = (%v, %t), want (%v, %t)", got, limited, tt.want, tt.limited)
+			}
+		})
+	}
+}
+
+// rateLimitedServer answers the first `limited` requests with a primary rate
+// limit that resets 30 seconds after now, then succeeds.
+func rateLimitedServer(t *testing.T, limited int, now time.Time) (*httptest.Server, *int) {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		if calls <= limited {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(30*time.Second).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintln(w, `{"message":"API rate limit exceeded"}`)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		fmt.Fprintln(w, `[{"name":"repo-one","clone_url":"https://github.com/acme/repo-one.git","default_branch":"main"}]`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestListRepos_WaitsForRateLimitReset(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	server, calls := rateLimitedServer(t, 1, now)
+
+	var logs []string
+	client := NewClient(server.URL, "", func(format string, args ...any) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}, nil)
+	client.SetRateLimitWait(5 * time.Minute)
+	client.now = func() time.Time { return now }
+	var slept []time.Duration
+	client.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	repos, err := client.ListOrgRepos("acme")
+	if err != nil {
+		t.Fatalf("ListOrgRepos returned error: %v", err)
+	}
+	if len(repos) != 1 || *calls != 2 {
+		t.Fatalf("expected 1 repo after 2 calls, got %d repos after %d calls", len(repos), *calls)
+	}
+	if len(slept) != 1 || slept[0] != 31*time.Second {
+		t.Fatalf("unexpected sleeps: %v", slept)
+	}
+	joined := strings.Join(logs, "\n")
+	if !strings.Contains(joined, "api rate limit: remaining=4999 limit=5000") {
+		t.Fatalf("missing remaining quota in verbose logs: %s", joined)
+	}
+	if !strings.Contains(joined, "waiting 31s before retrying") {
+		t.Fatalf("missing wait message in verbose logs: %s", joined)
+	}
+}
+
+func TestListRepos_RateLimitBeyondMaxWaitFails(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	server, calls := rateLimitedServer(t, 1, now)
+
+	client := NewClient(server.URL, "", nil, nil)
+	client.SetRateLimitWait(10 * time.Second)
+	client.now = func() time.Time { return now }
+	client.sleep = func(time.Duration) { t.Fatal("unexpected sleep") }
+
+	_, err := client.ListOrgRepos("acme")
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("expected *RateLimitError, got %v", err)
+	}
+	if rlErr.StatusCode != http.StatusForbidden || !rlErr.Reset.Equal(now.Add(31*time.Second)) {
+		t.Fatalf("unexpected error fields: %+v", rlErr)
+	}
+	if strings.Contains(err.Error(), "check your token") {
+		t.Fatalf("rate limit reported as auth failure: %v", err)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected 1 call, got %d", *calls)
+	}
+}
+
+func TestListRepos_ForbiddenWithQuotaIsAuthError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4000")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, `{"message":"Resource not accessible by integration"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", nil, nil)
+	client.SetRateLimitWait(time.Hour)
+	client.sleep = func(time.Duration) { t.Fatal("unexpected sleep") }
+
+	_, err := client.ListOrgRepos("acme")
+	if err == nil || !strings.Contains(err.Error(), "check your token") {
+		t.Fatalf("expected auth error, got %v", err)
+	}
+}
+
+func TestListRepos_RetryAfterZeroIsBounded(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	for _, budget := range []time.Duration{5 * time.Minute, 0} {
+		calls = 0
+		client := NewClient(server.URL, "", nil, nil)
+		client.SetRateLimitWait(budget)
+		var slept time.Duration
+		client.sleep = func(d time.Duration) { slept += d }
+
+		_, err := client.ListOrgRepos("acme")
+		var rlErr *RateLimitError
+		if !errors.As(err, &rlErr) {
+			t.Fatalf("budget %s: expected *RateLimitError, got %v", budget, err)
+		}
+		if calls > maxRateLimitRetries+1 || slept > budget {
+			t.Errorf("budget %s: %d calls, slept %s", budget, calls, slept)
+		}
+		if budget == 0 && calls != 1 {
+			t.Errorf("budget 0: expected no retries, got %d calls", calls)
+		}
+	}
+}
@@ -1,12 +1,43 @@
 package model
 
+import (
+	"path/filepath"
+	"time"
+)
+
 // RepoInfo represents a GitHub repository from the org inventory.
 type RepoInfo struct {
+	ID            int64 // GitHub repository ID; unchanged when the repository is renamed
 	Name          string
-	CloneURL      string
+	Owner         string // organization or user login the repository was listed for
+	Directory     string // workspace subdirectory the repository is cloned into; empty for the workspace root
+	CloneURL      string // HTTPS clone URL
+	SSHURL        string // SSH clone URL (git@host:owner/name.git)
 	DefaultBranch string
 	IsPrivate     bool
 	IsArchived    bool
+	IsFork        bool
+	IsTemplate    bool
+	Language      string // primary language detected by GitHub; empty when unknown
+	Topics        []string
+	PushedAt      time.Time // last push to any branch; zero for never-pushed repos
+	UpdatedAt     time.Time // last change to the repository object
+	Stale         bool      // no activity within active_within, kept only because it is already cloned
+}
+
+// Path returns where the repository is cloned, relative to the workspace
+// root.
+func (r RepoInfo) Path() string {
+	return filepath.Join(r.Directory, r.Name)
+}
+
+// LastActivity returns when the repository last saw activity: its last push,
+// or its last update for repositories that were never pushed to.
+func (r RepoInfo) LastActivity() time.Time {
+	if r.PushedAt.IsZero() {
+		return r.UpdatedAt
+	}
+	return r.PushedAt
 }
 
 // LocalClassification represents the classification of a local directory entry.
@@ -17,6 +48,8 @@ const (
 	ClassUnknown                                      // No matching repo (included or excluded)
 	ClassExcludedButPresent                           // Matches an excluded repo name/pattern
 	ClassCollision                                    // Path exists but is not a valid clone
+	ClassRenamed                                      // Clone of an included repo under its old name (see LocalEntry.Target)
+	ClassOrphaned                                     // Clone of an owner's repo that is no longer in the inventory
 )
 
 // String returns a human-readable name for the classification.
@@ -30,6 +63,10 @@ func (c LocalClassification) String() string {
 		return "excluded-but-present"
 	case ClassCollision:
 		return "collision"
+	case ClassRenamed:
+		return "renamed"
+	case ClassOrphaned:
+		return "orphaned"
 	default:
 		return "unknown"
 	}
@@ -50,6 +87,11 @@ const (
 	ActionCheckoutError            // Checkout failed
 	ActionPullError                // Pull failed
 	ActionSubmoduleError           // Submodule update failed
+	ActionRemoteMismatch           // origin points at a different owner/name (checkout/pull skipped)
+	ActionDiverged                 // Local and upstream both have new commits (pull skipped)
+	ActionDetachedHead             // HEAD is not on a branch (checkout/pull skipped)
+	ActionInProgress               // A merge, rebase, cherry-pick, revert, or bisect is in progress (checkout/pull skipped)
+	ActionDefaultBranchRenamed     // Default branch was renamed upstream (see RepoResult.PreviousDefault and BranchMigrated)
 )
 
 // String returns a human-readable name for the action.
@@ -77,31 +119,148 @@ func (a RepoAction) String() string {
 		return "pull-error"
 	case ActionSubmoduleError:
 		return "submodule-error"
+	case ActionRemoteMismatch:
+		return "remote-mismatch"
+	case ActionDiverged:
+		return "diverged"
+	case ActionDetachedHead:
+		return "detached-head"
+	case ActionInProgress:
+		return "in-progress"
+	case ActionDefaultBranchRenamed:
+		return "default-branch-renamed"
 	default:
 		return "unknown"
 	}
 }
 
+// ErrorCategory classifies the cause of a failed git operation.
+type ErrorCategory int
+
+const (
+	ErrorUncategorized  ErrorCategory = iota // Cause not recognised
+	ErrorNetwork                             // Connection failure or transient server error
+	ErrorAuth                                // Credentials missing, rejected, or lacking access
+	ErrorNotFound                            // Repository or ref does not exist
+	ErrorNonFastForward                      // Local branch cannot be fast-forwarded to its upstream
+	ErrorLock                                // Another git process holds a lock file
+	ErrorDiskFull                            // No space left on device or quota exceeded
+)
+
+// String returns a human-readable name for the category.
+func (c ErrorCategory) String() string {
+	switch c {
+	case ErrorNetwork:
+		return "network"
+	case ErrorAuth:
+		return "auth"
+	case ErrorNotFound:
+		return "not-found"
+	case ErrorNonFastForward:
+		return "non-fast-forward"
+	case ErrorLock:
+		return "lock"
+	case ErrorDiskFull:
+		return "disk-full"
+	default:
+		return "other"
+	}
+}
+
+// ChangeKind classifies an entry in git status output.
+type ChangeKind int
+
+const (
+	ChangeOrdinary  ChangeKind = iota // Modified, added, deleted, or type-changed
+	ChangeRenamed                     // Renamed from OrigPath
+	ChangeCopied                      // Copied from OrigPath
+	ChangeUnmerged                    // Conflicted during a merge, rebase, or similar
+	ChangeUntracked                   // Not tracked by git
+)
+
+// String returns a human-readable name for the kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeRenamed:
+		return "renamed"
+	case ChangeCopied:
+		return "copied"
+	case ChangeUnmerged:
+		return "unmerged"
+	case ChangeUntracked:
+		return "untracked"
+	default:
+		return "ordinary"
+	}
+}
+
 // DirtyFile represents a single changed file in a dirty repo.
 type DirtyFile struct {
 	Path     string
+	OrigPath string // source path of a rename or copy
+	Kind     ChangeKind
+	XY       string // index and worktree status codes, e.g. "M." or "UU"; "??" for untracked
 	Staged   bool
 	Unstaged bool
 }
 
+// RepoStatus is the state of a working tree as reported by one git status
+// call: the current branch, its upstream, and every changed file.
+type RepoStatus struct {
+	Branch   string // current branch; "HEAD" when detached, as rev-parse --abbrev-ref reports it
+	Upstream string // empty when the branch has no upstream
+	Ahead    int    // commits not on the upstream
+	Behind   int    // upstream commits not on the branch
+	Files    []DirtyFile
+}
+
+// BranchState describes a local branch relative to its upstream.
+type BranchState struct {
+	Name     string
+	Current  bool   // checked out in the working tree
+	Upstream string // e.g. "origin/main"; empty when no upstream is configured
+	Gone     bool   // upstream is configured but no longer exists on the remote
+	Ahead    int    // commits not on the upstream
+	Behind   int    // upstream commits not on the branch
+	// Unpublished counts the commits on no remote-tracking branch, and
+	// Published is true when there are none. Both are checked only for
+	// branches without a live upstream.
+	Unpublished int
+	Published   bool
+}
+
+// AtRisk reports whether the branch holds commits that exist only locally:
+// commits ahead of its upstream, or, for a branch without a live upstream,
+// commits that no remote-tracking branch contains.
+func (b BranchState) AtRisk() bool {
+	return b.Ahead > 0 || (b.Upstream == "" || b.Gone) && !b.Published
+}
+
 // RepoResult holds the outcome of processing a single repository.
 type RepoResult struct {
-	Name          string
-	Action        RepoAction
-	CurrentBranch string
-	DefaultBranch string
-	Error         error
-	DirtyFiles    []DirtyFile
-	Additions     int
-	Deletions     int
-	BranchDrift   bool   // true if current != default branch at start
-	Updated       bool   // true if pull brought new changes
-	StatusOutput  string // colorized git status --short output (used by --status mode)
+	Name             string // path relative to the workspace root (see RepoInfo.Path)
+	Owner            string
+	Action           RepoAction
+	CurrentBranch    string
+	DefaultBranch    string
+	Error            error
+	ErrorCategory    ErrorCategory // cause of Error, when it came from git
+	DirtyFiles       []DirtyFile
+	Additions        int
+	Deletions        int
+	BranchDrift      bool          // true if current != default branch at start
+	Updated          bool          // true if pull brought new changes
+	StatusOutput     string        // colorized git status --short output (used by --status mode)
+	RemoteURL        string        // origin URL of an existing clone, when it could be read
+	ProtocolMismatch bool          // true if origin uses a different protocol than clone_protocol
+	Ahead            int           // commits only on the local branch (set for ActionDiverged)
+	Behind           int           // commits only on the upstream branch (set for ActionDiverged)
+	Branches         []BranchState // local branches, when they could be read
+	Stashes          int           // number of stash entries
+	Operation        string        // in-progress git operation, e.g. "rebase" (set for ActionInProgress)
+	PreviousDefault  string        // former default branch, when the local one was left behind by a rename upstream
+	BranchMigrated   bool          // true if the local PreviousDefault branch was renamed to DefaultBranch, or deleted when DefaultExisted
+	DefaultExisted   bool          // true if a local DefaultBranch existed next to the PreviousDefault branch
 }
 
 // LocalEntry represents a classified local directory entry.
@@ -109,16 +268,64 @@ type LocalEntry struct {
 	Name           string
 	Classification LocalClassification
 	Detail         string // additional info (e.g., collision reason)
+	Target         string // for ClassRenamed, the path of the repository the entry is a clone of
+	Unpushed       bool   // for ClassOrphaned, whether the clone holds unpushed branches or stash entries
+	Owner          string // for ClassCollision, the owner of the repository that claimed the path
 }
 
 // Summary holds aggregate counts for the final report.
 type Summary struct {
-	TotalRepos         int
-	Cloned             int
-	Updated            int
-	Dirty              int
-	BranchDrift        int
-	UnknownFolders     int
-	ExcludedButPresent int
-	Errors             int
+	TotalRepos           int
+	Cloned               int
+	Updated              int
+	Dirty                int
+	BranchDrift          int
+	RemoteMismatch       int
+	Diverged             int
+	Unpushed             int // repos with local-only commits or stash entries
+	DetachedHead         int
+	InProgress           int
+	Renamed              int // clones found under a repository's old name
+	DefaultBranchRenamed int // clones whose default branch was renamed upstream, migrated or not
+	UnknownFolders       int
+	Orphaned             int // clones of repositories deleted or transferred upstream
+	ExcludedButPresent   int
+	Errors               int
+}
+
+// Add adds the counts in o to s.
+func (s *Summary) Add(o Summary) {
+	s.TotalRepos += o.TotalRepos
+	s.Cloned += o.Cloned
+	s.Updated += o.Updated
+	s.Dirty += o.Dirty
+	s.BranchDrift += o.BranchDrift
+	s.RemoteMismatch += o.RemoteMismatch
+	s.Diverged += o.Diverged
+	s.Unpushed += o.Unpushed
+	s.DetachedHead += o.DetachedHead
+	s.InProgress += o.InProgress
+	s.Renamed += o.Renamed
+	s.DefaultBranchRenamed += o.DefaultBranchRenamed
+	s.UnknownFolders += o.UnknownFolders
+	s.Orphaned += o.Orphaned
+	s.ExcludedButPresent += o.ExcludedButPresent
+	s.Errors += o.Errors
+}
+
+// UnpushedBranches returns the branches whose commits exist only locally.
+func (r RepoResult) UnpushedBranches() []BranchState {
+	var at []BranchState
+	for _, b := range r.Branches {
+		if b.AtRisk() {
+			at = append(at, b)
+		}
+	}
+	return at
+}
+
+// HasUnpushedWork reports whether the clone holds work that would be lost if
+// it were deleted: unpushed branches or stash entries.
+func (r RepoResult) HasUnpushedWork() bool {
+	return r.Stashes > 0 || len(r.UnpushedBranches()) > 0
 }
@@ -1,6 +1,9 @@
 package model
 
-import "testing"
+import (
+	"path/filepath"
+	"testing"
+)
 
 func TestLocalClassificationString(t *testing.T) {
 	tests := []struct {
@@ -11,6 +14,8 @@ func TestLocalClassificationString(t *testing.T) {
 		{ClassUnknown, "unknown"},
 		{ClassExcludedButPresent, "excluded-but-present"},
 		{ClassCollision, "collision"},
+		{ClassRenamed, "renamed"},
+		{ClassOrphaned, "orphaned"},
 		{LocalClassification(99), "unknown"},
 	}
 	for _, tt := range tests {
@@ -36,6 +41,11 @@ func TestRepoActionString(t *testing.T) {
 		{ActionCheckoutError, "checkout-error"},
 		{ActionPullError, "pull-error"},
 		{ActionSubmoduleError, "submodule-error"},
+		{ActionRemoteMismatch, "remote-mismatch"},
+		{ActionDiverged, "diverged"},
+		{ActionDetachedHead, "detached-head"},
+		{ActionInProgress, "in-progress"},
+		{ActionDefaultBranchRenamed, "default-branch-renamed"},
 		{RepoAction(99), "unknown"},
 	}
 	for _, tt := range tests {
@@ -44,3 +54,82 @@ func TestRepoActionString(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorCategoryString(t *testing.T) {
+	tests := []struct {
+		c    ErrorCategory
+		want string
+	}{
+		{ErrorUncategorized, "other"},
+		{ErrorNetwork, "network"},
+		{ErrorAuth, "auth"},
+		{ErrorNotFound, "not-found"},
+		{ErrorNonFastForward, "non-fast-forward"},
+		{ErrorLock, "lock"},
+		{ErrorDiskFull, "disk-full"},
+		{ErrorCategory(99), "other"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("ErrorCategory(%d).String() = %q, want %q", int(tt.c), got, tt.want)
+		}
+	}
+}
+
+func TestChangeKindString(t *testing.T) {
+	tests := []struct {
+		k    ChangeKind
+		want string
+	}{
+		{ChangeOrdinary, "ordinary"},
+		{ChangeRenamed, "renamed"},
+		{ChangeCopied, "copied"},
+		{ChangeUnmerged, "unmerged"},
+		{ChangeUntracked, "untracked"},
+	}
+	for _, tt := range tests {
+		if got := tt.k.String(); got != tt.want {
+			t.Errorf("ChangeKind(%d).String() = %q, want %q", int(tt.k), got, tt.want)
+		}
+	}
+}
+
+func TestRepoResult_HasUnpushedWork(t *testing.T) {
+	tests := []struct {
+		name   string
+		result RepoResult
+		want   bool
+	}{
+		{"no branches", RepoResult{}, false},
+		{"in sync", RepoResult{Branches: []BranchState{{Name: "main", Upstream: "origin/main", Behind: 2}}}, false},
+		{"ahead", RepoResult{Branches: []BranchState{{Name: "main", Upstream: "origin/main", Ahead: 1}}}, true},
+		{"no upstream", RepoResult{Branches: []BranchState{{Name: "local-only"}}}, true},
+		{"no upstream, pushed elsewhere", RepoResult{Branches: []BranchState{{Name: "copy", Published: true}}}, false},
+		{"upstream gone", RepoResult{Branches: []BranchState{{Name: "merged", Upstream: "origin/merged", Gone: true, Unpublished: 2}}}, true},
+		{"upstream gone, merged", RepoResult{Branches: []BranchState{{Name: "merged", Upstream: "origin/merged", Gone: true, Published: true}}}, false},
+		{"stash only", RepoResult{Stashes: 2}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.result.HasUnpushedWork(); got != tt.want {
+			t.Errorf("%s: HasUnpushedWork() = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRepoInfo_Path(t *testing.T) {
+	if got := (RepoInfo{Name: "api"}).Path(); got != "api" {
+		t.Errorf("Path() = %q, want %q", got, "api")
+	}
+	if got := (RepoInfo{Name: "api", Directory: "acme"}).Path(); got != filepath.Join("acme", "api") {
+		t.Errorf("Path() = %q, want %q", got, filepath.Join("acme", "api"))
+	}
+}
+
+func TestSummary_Add(t *testing.T) {
+	s := Summary{TotalRepos: 2, Cloned: 1, UnknownFolders: 1}
+	s.Add(Summary{TotalRepos: 3, Cloned: 2, Errors: 1})
+	want := Summary{TotalRepos: 5, Cloned: 3, UnknownFolders: 1, Errors: 1}
+	if s != want {
+		t.Errorf("Add() = %+v, want %+v", s, want)
+	}
+}
@@ -3,10 +3,15 @@ package output
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 
 	"golang.org/x/term"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
 )
 
 // ANSI color codes
@@ -66,7 +71,11 @@ func digitCount(n int) int {
 }
 
 // Printer handles formatted output with optional color support.
+// It is safe for concurrent use: each call writes its complete block while
+// holding the printer lock, so output from parallel workers never interleaves.
 type Printer struct {
+	mu           sync.Mutex
+	out          io.Writer // destination for all output; nil means os.Stdout
 	color        bool
 	verbosity    int // 0=quiet, 1=verbose, 2=trace
 	interactive  bool
@@ -105,6 +114,21 @@ func ShouldColor() bool {
 	return IsTerminalOutput()
 }
 
+// SetOutput redirects all printer output to w. The --output json mode uses it
+// to move human-readable output to stderr so stdout carries only the report.
+func (p *Printer) SetOutput(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.out = w
+}
+
+func (p *Printer) writer() io.Writer {
+	if p.out == nil {
+		return os.Stdout
+	}
+	return p.out
+}
+
 func (p *Printer) colorize(color, text string) string {
 	if !p.color {
 		return text
@@ -113,6 +137,8 @@ func (p *Printer) colorize(color, text string) string {
 }
 
 func (p *Printer) withProgressSuspended(fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	redraw := p.clearLiveProgressLine()
 	fn()
 	if redraw {
@@ -124,7 +150,7 @@ func (p *Printer) clearLiveProgressLine() bool {
 	if !p.repoProgress.active || !p.repoProgress.live {
 		return false
 	}
-	fmt.Print(clearLine)
+	fmt.Fprint(p.writer(), clearLine)
 	return true
 }
 
@@ -132,8 +158,8 @@ func (p *Printer) drawRepoProgressLine() {
 	if !p.repoProgress.active || !p.repoProgress.live {
 		return
 	}
-	fmt.Print(clearLine)
-	fmt.Print(p.repoProgressLine())
+	fmt.Fprint(p.writer(), clearLine)
+	fmt.Fprint(p.writer(), p.repoProgressLine())
 }
 
 func (p *Printer) repoProgressLine() string {
@@ -223,6 +249,8 @@ func (p *Printer) renderProgressLine(termWidth int) string {
 
 // StartRepoProgress starts a live progress line for repository processing.
 func (p *Printer) StartRepoProgress(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if total <= 0 {
 		p.repoProgress = repoProgressState{}
 		return
@@ -240,7 +268,10 @@ func (p *Printer) StartRepoProgress(total int) {
 }
 
 // AdvanceRepoProgress increments the repository progress bar by one.
+// It may be called from worker goroutines as each repository finishes.
 func (p *Printer) AdvanceRepoProgress() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if !p.repoProgress.active {
 		return
 	}
@@ -254,6 +285,8 @@ func (p *Printer) AdvanceRepoProgress() {
 
 // FinishRepoProgress renders the completed progress line and moves to the next line.
 func (p *Printer) FinishRepoProgress() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if !p.repoProgress.active {
 		return
 	}
@@ -262,7 +295,7 @@ func (p *Printer) FinishRepoProgress() {
 	}
 	if p.repoProgress.live {
 		p.drawRepoProgressLine()
-		fmt.Println()
+		fmt.Fprintln(p.writer())
 	}
 	p.repoProgress = repoProgressState{}
 }
@@ -270,7 +303,7 @@ func (p *Printer) FinishRepoProgress() {
 // Header prints a section header.
 func (p *Printer) Header(text string) {
 	p.withProgressSuspended(func() {
-		fmt.Println(p.colorize(bold, text))
+		fmt.Fprintln(p.writer(), p.colorize(bold, text))
 	})
 }
 
@@ -281,7 +314,7 @@ func (p *Printer) Verbose(format string, args ...any) {
 	}
 	msg := fmt.Sprintf(format, args...)
 	p.withProgressSuspended(func() {
-		fmt.Println(p.colorize(gray, "  "+msg))
+		fmt.Fprintln(p.writer(), p.colorize(gray, "  "+msg))
 	})
 }
 
@@ -294,7 +327,7 @@ func (p *Printer) Trace(format string, args ...any) {
 	msg := fmt.Sprintf(format, args...)
 	p.withProgressSuspended(func() {
 		for line := range strings.SplitSeq(strings.TrimRight(msg, "\n"), "\n") {
-			fmt.Println(p.colorize(gray, "  "+line))
+			fmt.Fprintln(p.writer(), p.colorize(gray, "  "+line))
 		}
 	})
 }
@@ -302,7 +335,7 @@ func (p *Printer) Trace(format string, args ...any) {
 // RepoCloned prints a clone action.
 func (p *Printer) RepoCloned(name string) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
 			p.colorize(cyan, "repo"),
 			p.colorize(bold, name),
 			p.colorize(green, "[cloned]"))
@@ -312,7 +345,7 @@ func (p *Printer) RepoCloned(name string) {
 // RepoUpdated prints an update action.
 func (p *Printer) RepoUpdated(name string) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
 			p.colorize(cyan, "repo"),
 			p.colorize(bold, name),
 			p.colorize(green, "[updated]"))
@@ -326,13 +359,112 @@ func (p *Printer) RepoBranchDrift(name, fromBranch, toBranch string, updated boo
 		status = "[branch-drift: checked out " + toBranch + ", updated]"
 	}
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
 			p.colorize(cyan, "repo"),
 			p.colorize(bold, name),
 			p.colorize(yellow, status))
 	})
 }
 
+// RepoDefaultBranchRenamed prints a repo whose default branch was renamed
+// upstream. When migrated, the old local branch was renamed, or deleted when
+// the new one existed, and the clone synced. Otherwise the clone was left
+// untouched, unless the new branch existed and was synced as usual.
+func (p *Printer) RepoDefaultBranchRenamed(name, from, to string, migrated, existed, updated bool) {
+	p.withProgressSuspended(func() {
+		if !migrated {
+			label := "[default-branch-renamed]"
+			hint := "checkout/pull skipped; run with --migrate-default-branch to rename the local branch"
+			if existed {
+				if updated {
+					label = "[default-branch-renamed, updated]"
+				}
+				hint = "local " + from + " still exists; --migrate-default-branch deletes it once merged into " + to
+			}
+			fmt.Fprintf(p.writer(), "  %s %s %s %s was renamed to %s upstream\n",
+				p.colorize(cyan, "repo"),
+				p.colorize(bold, name),
+				p.colorize(yellow, label),
+				from, to)
+			fmt.Fprintf(p.writer(), "       %s\n",
+				p.colorize(yellow, hint))
+			return
+		}
+		status := "[default-branch-renamed: " + from + " -> " + to + "]"
+		if updated {
+			status = "[default-branch-renamed: " + from + " -> " + to + ", updated]"
+		}
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
+			p.colorize(cyan, "repo"),
+			p.colorize(bold, name),
+			p.colorize(green, status))
+	})
+}
+
+// RepoDiverged prints a repo whose branch and upstream both have new commits,
+// so it cannot be fast-forwarded.
+func (p *Printer) RepoDiverged(name, branch string, ahead, behind int) {
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s %s %s: %s local-only, %s remote-only\n",
+			p.colorize(cyan, "repo"),
+			p.colorize(bold, name),
+			p.colorize(yellow, "[diverged]"),
+			branch,
+			pluralCommits(ahead),
+			pluralCommits(behind))
+		fmt.Fprintf(p.writer(), "       %s\n",
+			p.colorize(yellow, "pull skipped; merge or rebase manually"))
+	})
+}
+
+// RepoDetachedHead prints a repo whose HEAD is not on a branch.
+func (p *Printer) RepoDetachedHead(name string) {
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
+			p.colorize(cyan, "repo"),
+			p.colorize(bold, name),
+			p.colorize(yellow, "[detached-head]"))
+		fmt.Fprintf(p.writer(), "       %s\n",
+			p.colorize(yellow, "left untouched; check out a branch to resume syncing"))
+	})
+}
+
+// RepoInProgress prints a repo with a git operation (merge, rebase, etc.)
+// stopped part-way. branch is omitted when it is "HEAD", as during a rebase.
+func (p *Printer) RepoInProgress(name, branch, operation string) {
+	p.withProgressSuspended(func() {
+		label := p.colorize(yellow, "["+operation+" in progress]")
+		if branch != "" && branch != "HEAD" {
+			label += " on " + branch
+		}
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
+			p.colorize(cyan, "repo"),
+			p.colorize(bold, name),
+			label)
+		fmt.Fprintf(p.writer(), "       %s\n",
+			p.colorize(yellow, fmt.Sprintf("left untouched; finish or abort the %s manually", operation)))
+	})
+}
+
+// RepoUnpushed prints work that exists only in the local clone: branches
+// ahead of or without an upstream, and stash entries.
+func (p *Printer) RepoUnpushed(name string, branches []model.BranchState, stashes int) {
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
+			p.colorize(cyan, "repo"),
+			p.colorize(bold, name),
+			p.colorize(yellow, "[unpushed]"))
+		for _, b := range branches {
+			fmt.Fprintf(p.writer(), "       %s\n", DescribeBranchRisk(b))
+		}
+		if stashes == 1 {
+			fmt.Fprintf(p.writer(), "       1 stash entry\n")
+		} else if stashes > 1 {
+			fmt.Fprintf(p.writer(), "       %d stash entries\n", stashes)
+		}
+	})
+}
+
 // RepoDirty prints a dirty repo finding.
 func (p *Printer) RepoDirty(name, currentBranch, defaultBranch string, files []DirtyFileInfo, additions, deletions int) {
 	branchInfo := currentBranch
@@ -340,41 +472,72 @@ func (p *Printer) RepoDirty(name, currentBranch, defaultBranch string, files []D
 		branchInfo = currentBranch + " (default: " + defaultBranch + ")"
 	}
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s on %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s on %s\n",
 			p.colorize(cyan, "repo"),
 			p.colorize(bold, name),
 			p.colorize(yellow, "[dirty]"),
 			branchInfo)
-		fmt.Printf("       %s\n",
+		fmt.Fprintf(p.writer(), "       %s\n",
 			p.colorize(yellow, "checkout/pull skipped due to dirty working tree"))
 
 		// Print changed files
 		for _, f := range files {
 			label := ""
-			if f.Staged && f.Unstaged {
+			if f.Unmerged {
+				label = "conflict"
+			} else if f.Staged && f.Unstaged {
 				label = "staged+unstaged"
 			} else if f.Staged {
 				label = "staged"
 			} else {
 				label = "unstaged"
 			}
-			fmt.Printf("       %s %s\n",
+			path := f.Path
+			if f.OrigPath != "" {
+				path = f.OrigPath + " -> " + f.Path
+			}
+			fmt.Fprintf(p.writer(), "       %s %s\n",
 				p.colorize(gray, "["+label+"]"),
-				f.Path)
+				path)
 		}
 
 		// Print line count summary
 		if additions > 0 || deletions > 0 {
-			fmt.Printf("       %s\n",
+			fmt.Fprintf(p.writer(), "       %s\n",
 				p.colorize(gray, fmt.Sprintf("+%d -%d lines", additions, deletions)))
 		}
 	})
 }
 
+// RepoProtocolMismatch prints a finding for a clone whose origin uses a
+// different protocol than the configured clone_protocol.
+func (p *Printer) RepoProtocolMismatch(name, actual, expected string) {
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
+			p.colorize(cyan, "repo"),
+			p.colorize(bold, name),
+			p.colorize(yellow, "[protocol-mismatch: origin uses "+actual+", clone_protocol is "+expected+"]"))
+	})
+}
+
+// RepoRemoteMismatch prints a finding for a managed repo whose origin points at
+// a different owner/name than the inventory entry.
+func (p *Printer) RepoRemoteMismatch(name string, err error) {
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s %s %s\n",
+			p.colorize(cyan, "repo"),
+			p.colorize(bold, name),
+			p.colorize(yellow, "[remote-mismatch]"),
+			err.Error())
+		fmt.Fprintf(p.writer(), "       %s\n",
+			p.colorize(yellow, "fetch/checkout/pull skipped due to unexpected origin"))
+	})
+}
+
 // RepoError prints a repo-level error.
 func (p *Printer) RepoError(name, action string, err error) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s %s\n",
 			p.colorize(cyan, "repo"),
 			p.colorize(bold, name),
 			p.colorize(red, "["+action+"]"),
@@ -382,6 +545,21 @@ func (p *Printer) RepoError(name, action string, err error) {
 	})
 }
 
+// RepoGitError prints a repo-level git failure followed by a remediation hint
+// for its category, when there is one.
+func (p *Printer) RepoGitError(name, action string, err error, category model.ErrorCategory) {
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s %s %s\n",
+			p.colorize(cyan, "repo"),
+			p.colorize(bold, name),
+			p.colorize(red, "["+action+"]"),
+			p.colorize(red, err.Error()))
+		if hint := ErrorHint(category); hint != "" {
+			fmt.Fprintf(p.writer(), "       %s\n", p.colorize(yellow, "hint: "+hint))
+		}
+	})
+}
+
 // RepoCleanupPlanned reports ignored content selected for cleanup or dry-run.
 func (p *Printer) RepoCleanupPlanned(name string, files int, size int64, dryRun bool) {
 	verb := "removed"
@@ -390,7 +568,7 @@ func (p *Printer) RepoCleanupPlanned(name string, files int, size int64, dryRun
 	}
 	content := fmt.Sprintf("%d files, %s", files, formatBytes(size))
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
 			p.colorize(cyan, "repo"),
 			p.colorize(bold, name),
 			p.colorize(yellow, "[cleanup: "+content+" would be "+verb+"]"))
@@ -400,7 +578,7 @@ func (p *Printer) RepoCleanupPlanned(name string, files int, size int64, dryRun
 // CleanupCancelled reports that the user declined the cleanup confirmation.
 func (p *Printer) CleanupCancelled() {
 	p.withProgressSuspended(func() {
-		fmt.Println(p.colorize(yellow, "  cleanup [cancelled]"))
+		fmt.Fprintln(p.writer(), p.colorize(yellow, "  cleanup [cancelled]"))
 	})
 }
 
@@ -408,10 +586,10 @@ func (p *Printer) CleanupCancelled() {
 func (p *Printer) ConfirmCleanup(name string) bool {
 	confirmed := false
 	p.withProgressSuspended(func() {
-		fmt.Printf("  repo %s cleanup: remove this ignored content? [y/N] ", name)
+		fmt.Fprintf(p.writer(), "  repo %s cleanup: remove this ignored content? [y/N] ", name)
 		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
 		if err != nil && len(answer) == 0 {
-			fmt.Println()
+			fmt.Fprintln(p.writer())
 			return
 		}
 		answer = strings.ToLower(strings.TrimSpace(answer))
@@ -442,17 +620,66 @@ func formatBytes(size int64) string {
 // UnknownFolder prints an unknown folder finding.
 func (p *Printer) UnknownFolder(name string) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
 			p.colorize(magenta, "folder"),
 			p.colorize(bold, name),
 			p.colorize(yellow, "[unknown]"))
 	})
 }
 
+// OrphanedFolder prints a finding for an unknown clone of a repository that
+// was deleted or transferred upstream, with any work that exists only in it.
+func (p *Printer) OrphanedFolder(name, detail string, branches []model.BranchState, stashes int) {
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s %s %s\n",
+			p.colorize(magenta, "folder"),
+			p.colorize(bold, name),
+			p.colorize(yellow, "[orphaned]"),
+			detail)
+		if len(branches) == 0 && stashes == 0 {
+			fmt.Fprintf(p.writer(), "       %s\n", p.colorize(green, "no unpushed work"))
+			return
+		}
+		for _, b := range branches {
+			fmt.Fprintf(p.writer(), "       %s\n", DescribeBranchRisk(b))
+		}
+		if stashes == 1 {
+			fmt.Fprintf(p.writer(), "       1 stash entry\n")
+		} else if stashes > 1 {
+			fmt.Fprintf(p.writer(), "       %d stash entries\n", stashes)
+		}
+	})
+}
+
+// RepoRenamed prints a finding for an unknown clone that belongs to a
+// repository now expected at target, such as after a rename on GitHub.
+func (p *Printer) RepoRenamed(name, target, detail string) {
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s %s %s\n",
+			p.colorize(magenta, "folder"),
+			p.colorize(bold, name),
+			p.colorize(yellow, "[renamed]"),
+			detail)
+		fmt.Fprintf(p.writer(), "       %s\n",
+			p.colorize(yellow, "not cloned again; run with --adopt-renames to move it to "+target+" and update origin"))
+	})
+}
+
+// RepoAdopted prints that a renamed clone was moved to its repository's path.
+func (p *Printer) RepoAdopted(name, target string) {
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s %s moved from %s\n",
+			p.colorize(cyan, "repo"),
+			p.colorize(bold, target),
+			p.colorize(green, "[adopted]"),
+			name)
+	})
+}
+
 // ExcludedButPresent prints an excluded-but-present finding.
 func (p *Printer) ExcludedButPresent(name string) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
 			p.colorize(magenta, "folder"),
 			p.colorize(bold, name),
 			p.colorize(yellow, "[excluded-but-present]"))
@@ -462,7 +689,7 @@ func (p *Printer) ExcludedButPresent(name string) {
 // Collision prints a path collision finding.
 func (p *Printer) Collision(name, detail string) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s %s\n",
 			p.colorize(cyan, "repo"),
 			p.colorize(bold, name),
 			p.colorize(red, "[collision]"),
@@ -473,7 +700,7 @@ func (p *Printer) Collision(name, detail string) {
 // SystemError prints a system-level error.
 func (p *Printer) SystemError(context string, err error) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
 			p.colorize(red, "system"),
 			p.colorize(bold, context),
 			p.colorize(red, err.Error()))
@@ -487,7 +714,7 @@ func (p *Printer) RepoStatusDirty(name, currentBranch, defaultBranch, statusOutp
 		branchInfo = currentBranch + " (default: " + defaultBranch + ")"
 	}
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s on %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s on %s\n",
 			p.colorize(cyan, "repo"),
 			p.colorize(bold, name),
 			p.colorize(yellow, "[dirty]"),
@@ -495,7 +722,7 @@ func (p *Printer) RepoStatusDirty(name, currentBranch, defaultBranch, statusOutp
 		// Print colorized git status output (already includes ANSI codes from git)
 		for line := range strings.SplitSeq(strings.TrimRight(statusOutput, "\n"), "\n") {
 			if line != "" {
-				fmt.Printf("       %s\n", line)
+				fmt.Fprintf(p.writer(), "       %s\n", line)
 			}
 		}
 	})
@@ -504,7 +731,7 @@ func (p *Printer) RepoStatusDirty(name, currentBranch, defaultBranch, statusOutp
 // RepoStatusBranchDrift prints a non-default branch finding in status mode.
 func (p *Printer) RepoStatusBranchDrift(name, currentBranch, defaultBranch string) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s on %s (default: %s)\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s on %s (default: %s)\n",
 			p.colorize(cyan, "repo"),
 			p.colorize(bold, name),
 			p.colorize(yellow, "[branch-drift]"),
@@ -514,91 +741,75 @@ func (p *Printer) RepoStatusBranchDrift(name, currentBranch, defaultBranch strin
 }
 
 // StatusSummary prints the summary line for status mode.
-func (p *Printer) StatusSummary(total, dirty, branchDrift int) {
-	p.withProgressSuspended(func() {
-		fmt.Println()
-		fmt.Println(p.colorize(bold, "Summary:"))
-
-		parts := []string{
-			fmt.Sprintf("total: %d", total),
-		}
-
-		if dirty > 0 {
-			parts = append(parts, p.colorize(yellow, fmt.Sprintf("dirty: %d", dirty)))
-		} else {
-			parts = append(parts, fmt.Sprintf("dirty: %d", dirty))
-		}
-		if branchDrift > 0 {
-			parts = append(parts, p.colorize(yellow, fmt.Sprintf("branch-drift: %d", branchDrift)))
-		} else {
-			parts = append(parts, fmt.Sprintf("branch-drift: %d", branchDrift))
-		}
-
-		fmt.Println("  " + strings.Join(parts, " | "))
-	})
+func (p *Printer) StatusSummary(summary model.Summary) {
+	p.printSummary(StatusSummaryParts(summary))
 }
 
 // DirtyFileInfo is a simple struct for passing to Printer.
 type DirtyFileInfo struct {
 	Path     string
+	OrigPath string // source path of a rename or copy
 	Staged   bool
 	Unstaged bool
+	Unmerged bool
 }
 
 // Summary prints the final summary block.
-func (p *Printer) Summary(total, cloned, updated, dirty, branchDrift, unknown, excluded, errors int) {
+func (p *Printer) Summary(summary model.Summary) {
+	p.printSummary(SummaryParts(summary))
+}
+
+// printSummary prints the "Summary:" header and one line of counts.
+func (p *Printer) printSummary(parts []SummaryPart) {
 	p.withProgressSuspended(func() {
-		fmt.Println()
-		fmt.Println(p.colorize(bold, "Summary:"))
+		fmt.Fprintln(p.writer())
+		fmt.Fprintln(p.writer(), p.colorize(bold, "Summary:"))
+		fmt.Fprintln(p.writer(), "  "+p.renderParts(parts))
+	})
+}
 
-		parts := []string{
-			fmt.Sprintf("total: %d", total),
-		}
+// OwnerSummary prints one owner's counts below the summary block, for
+// workspaces that use owners.
+func (p *Printer) OwnerSummary(owner string, summary model.Summary) {
+	p.printOwnerSummary(owner, SummaryParts(summary))
+}
 
-		if cloned > 0 {
-			parts = append(parts, p.colorize(green, fmt.Sprintf("cloned: %d", cloned)))
-		} else {
-			parts = append(parts, fmt.Sprintf("cloned: %d", cloned))
-		}
-		if updated > 0 {
-			parts = append(parts, p.colorize(green, fmt.Sprintf("updated: %d", updated)))
-		} else {
-			parts = append(parts, fmt.Sprintf("updated: %d", updated))
-		}
-		if dirty > 0 {
-			parts = append(parts, p.colorize(yellow, fmt.Sprintf("dirty: %d", dirty)))
-		} else {
-			parts = append(parts, fmt.Sprintf("dirty: %d", dirty))
-		}
-		if branchDrift > 0 {
-			parts = append(parts, p.colorize(yellow, fmt.Sprintf("branch-drift: %d", branchDrift)))
-		} else {
-			parts = append(parts, fmt.Sprintf("branch-drift: %d", branchDrift))
-		}
-		if unknown > 0 {
-			parts = append(parts, p.colorize(yellow, fmt.Sprintf("unknown: %d", unknown)))
-		} else {
-			parts = append(parts, fmt.Sprintf("unknown: %d", unknown))
-		}
-		if excluded > 0 {
-			parts = append(parts, p.colorize(yellow, fmt.Sprintf("excluded-but-present: %d", excluded)))
-		} else {
-			parts = append(parts, fmt.Sprintf("excluded-but-present: %d", excluded))
-		}
-		if errors > 0 {
-			parts = append(parts, p.colorize(red, fmt.Sprintf("errors: %d", errors)))
-		} else {
-			parts = append(parts, fmt.Sprintf("errors: %d", errors))
-		}
+// OwnerStatusSummary prints one owner's status mode counts below the summary
+// block, for workspaces that use owners.
+func (p *Printer) OwnerStatusSummary(owner string, summary model.Summary) {
+	p.printOwnerSummary(owner, StatusSummaryParts(summary))
+}
 
-		fmt.Println("  " + strings.Join(parts, " | "))
+// printOwnerSummary prints one indented "owner: counts" line. Unknown,
+// orphaned, and excluded-but-present folders belong to the workspace rather
+// than an owner, so they are left out.
+func (p *Printer) printOwnerSummary(owner string, parts []SummaryPart) {
+	parts = slices.DeleteFunc(parts, func(part SummaryPart) bool {
+		return part.Label == "unknown" || part.Label == "orphaned" || part.Label == "excluded-but-present"
+	})
+	p.withProgressSuspended(func() {
+		fmt.Fprintf(p.writer(), "  %s %s\n", p.colorize(bold, owner+":"), p.renderParts(parts))
 	})
 }
 
+// renderParts joins summary counts into one line. Non-zero counts are
+// highlighted with their part's color.
+func (p *Printer) renderParts(parts []SummaryPart) string {
+	rendered := make([]string, len(parts))
+	for i, part := range parts {
+		text := fmt.Sprintf("%s: %d", part.Label, part.Count)
+		if part.Count > 0 && part.color != "" {
+			text = p.colorize(part.color, text)
+		}
+		rendered[i] = text
+	}
+	return strings.Join(rendered, " | ")
+}
+
 // ConfigError prints a configuration error message.
 func (p *Printer) ConfigError(err error) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("%s %s\n",
+		fmt.Fprintf(p.writer(), "%s %s\n",
 			p.colorize(red, "config error:"),
 			err.Error())
 	})
@@ -607,14 +818,14 @@ func (p *Printer) ConfigError(err error) {
 // MissingDotfile prints the message when .ghorgsync is not found.
 func (p *Printer) MissingDotfile(filename string) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("No %s configuration file found in current directory. Nothing to do.\n", filename)
+		fmt.Fprintf(p.writer(), "No %s configuration file found in current directory. Nothing to do.\n", filename)
 	})
 }
 
 // AuthError prints an authentication error message.
 func (p *Printer) AuthError(err error) {
 	p.withProgressSuspended(func() {
-		fmt.Printf("  %s %s %s\n",
+		fmt.Fprintf(p.writer(), "  %s %s %s\n",
 			p.colorize(red, "system"),
 			p.colorize(bold, "authentication"),
 			p.colorize(red, err.Error()))
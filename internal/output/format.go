@@ -1,14 +1,115 @@
 package output
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+)
+
+// SummaryPart is one labelled count in a summary line.
+type SummaryPart struct {
+	Label string
+	Count int
+	color string // highlight color used when Count is non-zero
+}
+
+// SummaryParts returns the counts shown in the default and clone-only mode summary, in display order.
+// Scripts scrape this line, so new counts are appended after the original
+// ones and existing columns never move.
+func SummaryParts(s model.Summary) []SummaryPart {
+	return []SummaryPart{
+		{Label: "total", Count: s.TotalRepos},
+		{Label: "cloned", Count: s.Cloned, color: green},
+		{Label: "updated", Count: s.Updated, color: green},
+		{Label: "dirty", Count: s.Dirty, color: yellow},
+		{Label: "branch-drift", Count: s.BranchDrift, color: yellow},
+		{Label: "unknown", Count: s.UnknownFolders, color: yellow},
+		{Label: "excluded-but-present", Count: s.ExcludedButPresent, color: yellow},
+		{Label: "errors", Count: s.Errors, color: red},
+		{Label: "remote-mismatch", Count: s.RemoteMismatch, color: yellow},
+		{Label: "diverged", Count: s.Diverged, color: yellow},
+		{Label: "unpushed", Count: s.Unpushed, color: yellow},
+		{Label: "detached", Count: s.DetachedHead, color: yellow},
+		{Label: "in-progress", Count: s.InProgress, color: yellow},
+		{Label: "renamed", Count: s.Renamed, color: yellow},
+		{Label: "orphaned", Count: s.Orphaned, color: yellow},
+		{Label: "default-branch-renamed", Count: s.DefaultBranchRenamed, color: yellow},
+	}
+}
+
+// StatusSummaryParts returns the counts shown in the status mode summary, in
+// display order. As with SummaryParts, new counts are appended.
+func StatusSummaryParts(s model.Summary) []SummaryPart {
+	return []SummaryPart{
+		{Label: "total", Count: s.TotalRepos},
+		{Label: "dirty", Count: s.Dirty, color: yellow},
+		{Label: "branch-drift", Count: s.BranchDrift, color: yellow},
+		{Label: "remote-mismatch", Count: s.RemoteMismatch, color: yellow},
+		{Label: "unpushed", Count: s.Unpushed, color: yellow},
+		{Label: "detached", Count: s.DetachedHead, color: yellow},
+		{Label: "in-progress", Count: s.InProgress, color: yellow},
+		{Label: "renamed", Count: s.Renamed, color: yellow},
+	}
+}
 
 // FormatSummaryLine builds a plain-text summary line (for testing).
-func FormatSummaryLine(total, cloned, updated, dirty, branchDrift, unknown, excluded, errors int) string {
-	return fmt.Sprintf("total: %d | cloned: %d | updated: %d | dirty: %d | branch-drift: %d | unknown: %d | excluded-but-present: %d | errors: %d",
-		total, cloned, updated, dirty, branchDrift, unknown, excluded, errors)
+func FormatSummaryLine(s model.Summary) string {
+	parts := SummaryParts(s)
+	rendered := make([]string, len(parts))
+	for i, part := range parts {
+		rendered[i] = fmt.Sprintf("%s: %d", part.Label, part.Count)
+	}
+	return strings.Join(rendered, " | ")
 }
 
 // FormatStatusLabel returns the text label for a repo action.
 func FormatStatusLabel(action string) string {
 	return "[" + action + "]"
 }
+
+// pluralCommits formats a commit count, e.g. "1 commit" or "3 commits".
+func pluralCommits(n int) string {
+	if n == 1 {
+		return "1 commit"
+	}
+	return fmt.Sprintf("%d commits", n)
+}
+
+// DescribeBranchRisk explains why a branch's commits exist only locally.
+func DescribeBranchRisk(b model.BranchState) string {
+	var desc string
+	switch {
+	case b.Upstream == "":
+		desc = b.Name + ": no upstream"
+	case b.Gone:
+		desc = b.Name + ": upstream " + b.Upstream + " is gone"
+	default:
+		return fmt.Sprintf("%s: ahead %d, behind %d (%s)", b.Name, b.Ahead, b.Behind, b.Upstream)
+	}
+	if b.Unpublished > 0 {
+		desc += ", " + pluralCommits(b.Unpublished) + " on no remote"
+	}
+	return desc
+}
+
+// ErrorHint returns a one-line remediation hint for a git failure category,
+// or "" when there is no specific advice.
+func ErrorHint(c model.ErrorCategory) string {
+	switch c {
+	case model.ErrorNetwork:
+		return "network failure persisted after retries; check connectivity and run again"
+	case model.ErrorAuth:
+		return "check that your git credentials (credential helper or SSH key) can access this repository"
+	case model.ErrorNotFound:
+		return "the repository or branch is missing on the remote, or your credentials cannot see it"
+	case model.ErrorNonFastForward:
+		return "the local branch has commits that are not on the remote; merge or rebase manually"
+	case model.ErrorLock:
+		return "another git process is using this repository; if none is running, remove the stale .lock file"
+	case model.ErrorDiskFull:
+		return "free up disk space and run again"
+	default:
+		return ""
+	}
+}
@@ -3,10 +3,26 @@ package output
 import (
 	"strings"
 	"testing"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
 )
 
 func TestFormatSummaryLine(t *testing.T) {
-	line := FormatSummaryLine(10, 2, 3, 1, 1, 2, 1, 0)
+	line := FormatSummaryLine(model.Summary{
+		TotalRepos:         10,
+		Cloned:             2,
+		Updated:            3,
+		Dirty:              1,
+		BranchDrift:        1,
+		RemoteMismatch:     1,
+		Diverged:           2,
+		DetachedHead:       1,
+		InProgress:         3,
+		Renamed:            1,
+		UnknownFolders:     2,
+		Orphaned:           1,
+		ExcludedButPresent: 1,
+	})
 	if !strings.Contains(line, "total: 10") {
 		t.Error("should contain total")
 	}
@@ -16,18 +32,44 @@ func TestFormatSummaryLine(t *testing.T) {
 	if !strings.Contains(line, "dirty: 1") {
 		t.Error("should contain dirty")
 	}
+	if !strings.Contains(line, "remote-mismatch: 1") {
+		t.Error("should contain remote-mismatch")
+	}
+	if !strings.Contains(line, "diverged: 2") {
+		t.Error("should contain diverged")
+	}
+	if !strings.Contains(line, "detached: 1") {
+		t.Error("should contain detached")
+	}
+	if !strings.Contains(line, "in-progress: 3") {
+		t.Error("should contain in-progress")
+	}
+	if !strings.Contains(line, "renamed: 1") {
+		t.Error("should contain renamed")
+	}
+	if !strings.Contains(line, "orphaned: 1") {
+		t.Error("should contain orphaned")
+	}
 	if !strings.Contains(line, "errors: 0") {
 		t.Error("should contain errors")
 	}
 }
 
 func TestFormatSummaryLine_AllZeros(t *testing.T) {
-	line := FormatSummaryLine(0, 0, 0, 0, 0, 0, 0, 0)
+	line := FormatSummaryLine(model.Summary{})
 	if !strings.Contains(line, "total: 0") {
 		t.Error("should contain total: 0")
 	}
 }
 
+func TestFormatSummaryLine_KeepsOriginalColumnsFirst(t *testing.T) {
+	line := FormatSummaryLine(model.Summary{TotalRepos: 4, Cloned: 1, Errors: 2, RemoteMismatch: 1})
+	prefix := "total: 4 | cloned: 1 | updated: 0 | dirty: 0 | branch-drift: 0 | unknown: 0 | excluded-but-present: 0 | errors: 2 | "
+	if !strings.HasPrefix(line, prefix) {
+		t.Errorf("summary line moved an original column:\n got %s\nwant prefix %s", line, prefix)
+	}
+}
+
 func TestFormatStatusLabel(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -44,6 +86,39 @@ func TestFormatStatusLabel(t *testing.T) {
 	}
 }
 
+func TestDescribeBranchRisk(t *testing.T) {
+	tests := []struct {
+		branch model.BranchState
+		want   string
+	}{
+		{model.BranchState{Name: "spike"}, "spike: no upstream"},
+		{model.BranchState{Name: "spike", Unpublished: 3}, "spike: no upstream, 3 commits on no remote"},
+		{model.BranchState{Name: "old", Upstream: "origin/old", Gone: true}, "old: upstream origin/old is gone"},
+		{model.BranchState{Name: "old", Upstream: "origin/old", Gone: true, Unpublished: 1}, "old: upstream origin/old is gone, 1 commit on no remote"},
+		{model.BranchState{Name: "main", Upstream: "origin/main", Ahead: 1}, "main: ahead 1, behind 0 (origin/main)"},
+		{model.BranchState{Name: "wip", Upstream: "origin/wip", Ahead: 3, Behind: 2}, "wip: ahead 3, behind 2 (origin/wip)"},
+	}
+	for _, tt := range tests {
+		if got := DescribeBranchRisk(tt.branch); got != tt.want {
+			t.Errorf("DescribeBranchRisk(%+v) = %q, want %q", tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestErrorHint(t *testing.T) {
+	for _, c := range []model.ErrorCategory{
+		model.ErrorNetwork, model.ErrorAuth, model.ErrorNotFound,
+		model.ErrorNonFastForward, model.ErrorLock, model.ErrorDiskFull,
+	} {
+		if ErrorHint(c) == "" {
+			t.Errorf("expected a hint for category %v", c)
+		}
+	}
+	if got := ErrorHint(model.ErrorUncategorized); got != "" {
+		t.Errorf("expected no hint for uncategorized errors, got %q", got)
+	}
+}
+
 func TestShouldColor_WithNoColorEnv(t *testing.T) {
 	// When NO_COLOR is set, ShouldColor should return false
 	t.Setenv("NO_COLOR", "1")
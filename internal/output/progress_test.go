@@ -2,6 +2,7 @@ package output
 
 import (
 	"strings"
+	"sync"
 	"testing"
 	"unicode/utf8"
 )
@@ -291,3 +292,18 @@ func TestRenderProgressLine_Layout(t *testing.T) {
 			repoIdx, counterIdx, bracketOpen, bracketClose, pctIdx)
 	}
 }
+
+func TestAdvanceRepoProgress_ConcurrentCallers(t *testing.T) {
+	p := newTestPrinter()
+	p.StartRepoProgress(50)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(p.AdvanceRepoProgress)
+	}
+	wg.Wait()
+
+	if p.repoProgress.current != 50 {
+		t.Errorf("current = %d after 50 concurrent advances, want 50", p.repoProgress.current)
+	}
+}
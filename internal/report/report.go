@@ -0,0 +1,264 @@
+// Package report builds the machine-readable JSON document written by
+// --output json. The layout is documented in docs/USAGE.md.
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+)
+
+// SchemaVersion identifies the layout of Report. It is incremented for any
+// incompatible change; fields may be added without changing the version.
+const SchemaVersion = 1
+
+// Report is the top-level JSON document.
+type Report struct {
+	SchemaVersion int            `json:"schema_version"`
+	Mode          string         `json:"mode"`            // "sync", "clone", or "status"
+	Owner         string         `json:"owner,omitempty"` // empty when the workspace uses owners
+	Repos         []Repo         `json:"repos"`
+	LocalEntries  []LocalEntry   `json:"local_entries"`
+	Cleanup       []Cleanup      `json:"cleanup"`
+	Summary       Summary        `json:"summary"`
+	Owners        []OwnerSummary `json:"owners,omitempty"` // per-owner counts when the workspace uses owners
+}
+
+// Repo is the outcome for one managed repository.
+type Repo struct {
+	Name             string      `json:"name"`
+	Owner            string      `json:"owner,omitempty"`
+	Action           string      `json:"action"`
+	CurrentBranch    string      `json:"current_branch,omitempty"`
+	DefaultBranch    string      `json:"default_branch"`
+	BranchDrift      bool        `json:"branch_drift"`
+	Updated          bool        `json:"updated"`
+	DirtyFiles       []DirtyFile `json:"dirty_files"`
+	Additions        int         `json:"additions"`
+	Deletions        int         `json:"deletions"`
+	RemoteURL        string      `json:"remote_url,omitempty"`
+	ProtocolMismatch bool        `json:"protocol_mismatch"`
+	Ahead            int         `json:"ahead,omitempty"`
+	Behind           int         `json:"behind,omitempty"`
+	Branches         []Branch    `json:"branches,omitempty"`
+	Stashes          int         `json:"stashes"`
+	Unpushed         bool        `json:"unpushed"`
+	Operation        string      `json:"operation,omitempty"`
+	PreviousDefault  string      `json:"previous_default_branch,omitempty"`
+	BranchMigrated   bool        `json:"branch_migrated,omitempty"`
+	DefaultExisted   bool        `json:"default_branch_existed,omitempty"`
+	Error            string      `json:"error,omitempty"`
+	ErrorCategory    string      `json:"error_category,omitempty"`
+}
+
+// Branch is the upstream tracking state of one local branch.
+type Branch struct {
+	Name     string `json:"name"`
+	Current  bool   `json:"current"`
+	Upstream string `json:"upstream,omitempty"`
+	Gone     bool   `json:"gone"`
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
+	// Unpublished is set only for branches without a live upstream.
+	Unpublished int `json:"unpublished,omitempty"`
+}
+
+// DirtyFile is one changed file in a dirty repository.
+type DirtyFile struct {
+	Path     string `json:"path"`
+	OrigPath string `json:"orig_path,omitempty"`
+	Kind     string `json:"kind"`
+	XY       string `json:"xy"`
+	Staged   bool   `json:"staged"`
+	Unstaged bool   `json:"unstaged"`
+}
+
+// LocalEntry is a classified local directory entry that is not a managed clone.
+type LocalEntry struct {
+	Name           string `json:"name"`
+	Classification string `json:"classification"`
+	Detail         string `json:"detail,omitempty"`
+	Target         string `json:"target,omitempty"`
+	Unpushed       *bool  `json:"unpushed,omitempty"` // orphaned entries only
+	Owner          string `json:"owner,omitempty"`    // collisions only
+}
+
+// Cleanup describes the ignored content selected by --clean for one repository.
+type Cleanup struct {
+	Repo    string   `json:"repo"`
+	Files   int      `json:"files"`
+	Dirs    int      `json:"dirs"`
+	Bytes   int64    `json:"bytes"`
+	DryRun  bool     `json:"dry_run"`
+	Removed bool     `json:"removed"` // every selected path was deleted
+	Paths   []string `json:"paths"`
+	Failed  []string `json:"failed,omitempty"` // paths whose removal failed
+}
+
+// Summary holds the aggregate counts for the run.
+type Summary struct {
+	TotalRepos           int `json:"total"`
+	Cloned               int `json:"cloned"`
+	Updated              int `json:"updated"`
+	Dirty                int `json:"dirty"`
+	BranchDrift          int `json:"branch_drift"`
+	RemoteMismatch       int `json:"remote_mismatch"`
+	Diverged             int `json:"diverged"`
+	Unpushed             int `json:"unpushed"`
+	DetachedHead         int `json:"detached_head"`
+	InProgress           int `json:"in_progress"`
+	Renamed              int `json:"renamed"`
+	DefaultBranchRenamed int `json:"default_branch_renamed"`
+	UnknownFolders       int `json:"unknown"`
+	Orphaned             int `json:"orphaned"`
+	ExcludedButPresent   int `json:"excluded_but_present"`
+	Errors               int `json:"errors"`
+}
+
+// OwnerSummary holds the counts for one owner of a workspace that uses owners.
+type OwnerSummary struct {
+	Owner     string  `json:"owner"`
+	Directory string  `json:"directory,omitempty"`
+	Summary   Summary `json:"summary"`
+}
+
+// New creates an empty report for the given mode and owner.
+func New(mode, owner string) *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		Mode:          mode,
+		Owner:         owner,
+		Repos:         []Repo{},
+		LocalEntries:  []LocalEntry{},
+		Cleanup:       []Cleanup{},
+	}
+}
+
+// AddRepo records the outcome of one repository.
+func (r *Report) AddRepo(result model.RepoResult) {
+	r.Repos = append(r.Repos, NewRepo(result))
+}
+
+// NewRepo converts a repository result to its JSON form.
+func NewRepo(result model.RepoResult) Repo {
+	repo := Repo{
+		Name:             result.Name,
+		Owner:            result.Owner,
+		Action:           result.Action.String(),
+		CurrentBranch:    result.CurrentBranch,
+		DefaultBranch:    result.DefaultBranch,
+		BranchDrift:      result.BranchDrift,
+		Updated:          result.Updated,
+		DirtyFiles:       make([]DirtyFile, len(result.DirtyFiles)),
+		Additions:        result.Additions,
+		Deletions:        result.Deletions,
+		RemoteURL:        result.RemoteURL,
+		ProtocolMismatch: result.ProtocolMismatch,
+		Ahead:            result.Ahead,
+		Behind:           result.Behind,
+		Stashes:          result.Stashes,
+		Unpushed:         result.HasUnpushedWork(),
+		Operation:        result.Operation,
+		PreviousDefault:  result.PreviousDefault,
+		BranchMigrated:   result.BranchMigrated,
+		DefaultExisted:   result.DefaultExisted,
+	}
+	for _, b := range result.Branches {
+		repo.Branches = append(repo.Branches, Branch{
+			Name:        b.Name,
+			Current:     b.Current,
+			Upstream:    b.Upstream,
+			Gone:        b.Gone,
+			Ahead:       b.Ahead,
+			Behind:      b.Behind,
+			Unpublished: b.Unpublished,
+		})
+	}
+	for i, f := range result.DirtyFiles {
+		repo.DirtyFiles[i] = DirtyFile{
+			Path:     f.Path,
+			OrigPath: f.OrigPath,
+			Kind:     f.Kind.String(),
+			XY:       f.XY,
+			Staged:   f.Staged,
+			Unstaged: f.Unstaged,
+		}
+	}
+	if result.Error != nil {
+		repo.Error = result.Error.Error()
+		if result.Action != model.ActionRemoteMismatch {
+			repo.ErrorCategory = result.ErrorCategory.String()
+		}
+	}
+	return repo
+}
+
+// AddLocalEntries records classified local directory entries.
+func (r *Report) AddLocalEntries(entries []model.LocalEntry) {
+	for _, e := range entries {
+		r.LocalEntries = append(r.LocalEntries, NewLocalEntry(e))
+	}
+}
+
+// NewLocalEntry converts a classified local entry to its JSON form.
+func NewLocalEntry(e model.LocalEntry) LocalEntry {
+	entry := LocalEntry{
+		Name:           e.Name,
+		Classification: e.Classification.String(),
+		Detail:         e.Detail,
+		Target:         e.Target,
+		Owner:          e.Owner,
+	}
+	if e.Classification == model.ClassOrphaned {
+		entry.Unpushed = &e.Unpushed
+	}
+	return entry
+}
+
+// AddCleanup records the cleanup plan for one repository.
+func (r *Report) AddCleanup(c Cleanup) {
+	if c.Paths == nil {
+		c.Paths = []string{}
+	}
+	r.Cleanup = append(r.Cleanup, c)
+}
+
+// SetSummary records the aggregate counts.
+func (r *Report) SetSummary(s model.Summary) {
+	r.Summary = NewSummary(s)
+}
+
+// AddOwner records the counts for one owner of a workspace that uses owners.
+func (r *Report) AddOwner(owner, directory string, s model.Summary) {
+	r.Owners = append(r.Owners, OwnerSummary{Owner: owner, Directory: directory, Summary: NewSummary(s)})
+}
+
+// NewSummary converts the aggregate counts to their JSON form.
+func NewSummary(s model.Summary) Summary {
+	return Summary{
+		TotalRepos:           s.TotalRepos,
+		Cloned:               s.Cloned,
+		Updated:              s.Updated,
+		Dirty:                s.Dirty,
+		BranchDrift:          s.BranchDrift,
+		RemoteMismatch:       s.RemoteMismatch,
+		Diverged:             s.Diverged,
+		Unpushed:             s.Unpushed,
+		DetachedHead:         s.DetachedHead,
+		InProgress:           s.InProgress,
+		Renamed:              s.Renamed,
+		DefaultBranchRenamed: s.DefaultBranchRenamed,
+		UnknownFolders:       s.UnknownFolders,
+		Orphaned:             s.Orphaned,
+		ExcludedButPresent:   s.ExcludedButPresent,
+		Errors:               s.Errors,
+	}
+}
+
+// Write encodes the report as indented JSON followed by a newline.
+func (r *Report) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,143 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+)
+
+func TestReport_WriteIncludesAllSections(t *testing.T) {
+	r := New("sync", "acme")
+	r.AddRepo(model.RepoResult{
+		Name:          "dirty-repo",
+		Action:        model.ActionDirty,
+		CurrentBranch: "feature",
+		DefaultBranch: "main",
+		BranchDrift:   true,
+		DirtyFiles:    []model.DirtyFile{{Path: "main.go", Staged: true}},
+		Additions:     3,
+		Deletions:     1,
+		Branches:      []model.BranchState{{Name: "feature", Current: true, Upstream: "origin/feature", Ahead: 2}},
+		Stashes:       1,
+	})
+	r.AddRepo(model.RepoResult{Name: "broken", Action: model.ActionFetchError, Error: errors.New("network down"), ErrorCategory: model.ErrorNetwork})
+	r.AddLocalEntries([]model.LocalEntry{
+		{Name: "stray", Classification: model.ClassUnknown},
+		{Name: "old-tool", Classification: model.ClassOrphaned, Detail: "origin acme/old-tool is no longer in the inventory"},
+	})
+	r.AddCleanup(Cleanup{Repo: "dirty-repo", Files: 2, Bytes: 42, DryRun: true})
+	r.SetSummary(model.Summary{TotalRepos: 2, Dirty: 1, Errors: 1, UnknownFolders: 1})
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if decoded["schema_version"] != float64(SchemaVersion) {
+		t.Errorf("schema_version = %v, want %d", decoded["schema_version"], SchemaVersion)
+	}
+	if decoded["mode"] != "sync" || decoded["owner"] != "acme" {
+		t.Errorf("unexpected mode/owner: %v/%v", decoded["mode"], decoded["owner"])
+	}
+
+	repos := decoded["repos"].([]any)
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repos, got %d", len(repos))
+	}
+	dirty := repos[0].(map[string]any)
+	if dirty["action"] != "dirty" || dirty["additions"] != float64(3) {
+		t.Errorf("unexpected dirty repo entry: %v", dirty)
+	}
+	if dirty["unpushed"] != true || dirty["stashes"] != float64(1) {
+		t.Errorf("expected unpushed work on dirty repo entry: %v", dirty)
+	}
+	if branches := dirty["branches"].([]any); len(branches) != 1 || branches[0].(map[string]any)["ahead"] != float64(2) {
+		t.Errorf("unexpected branches: %v", dirty["branches"])
+	}
+	if files := dirty["dirty_files"].([]any); len(files) != 1 {
+		t.Errorf("expected 1 dirty file, got %d", len(files))
+	} else if f := files[0].(map[string]any); f["kind"] != "ordinary" {
+		t.Errorf("unexpected dirty file entry: %v", f)
+	}
+	if broken := repos[1].(map[string]any); broken["error"] != "network down" || broken["error_category"] != "network" {
+		t.Errorf("expected error text and category, got %v/%v", broken["error"], broken["error_category"])
+	}
+	if _, ok := dirty["error_category"]; ok {
+		t.Errorf("unexpected error_category on a repo without an error: %v", dirty)
+	}
+
+	entries := decoded["local_entries"].([]any)
+	if len(entries) != 2 || entries[0].(map[string]any)["classification"] != "unknown" {
+		t.Fatalf("unexpected local entries: %v", entries)
+	}
+	if _, ok := entries[0].(map[string]any)["unpushed"]; ok {
+		t.Errorf("unexpected unpushed on an unknown entry: %v", entries[0])
+	}
+	if orphan := entries[1].(map[string]any); orphan["classification"] != "orphaned" || orphan["unpushed"] != false {
+		t.Errorf("expected an orphaned entry reporting unpushed=false, got %v", orphan)
+	}
+	cleanup := decoded["cleanup"].([]any)
+	if len(cleanup) != 1 || cleanup[0].(map[string]any)["paths"] == nil {
+		t.Errorf("unexpected cleanup entries: %v", cleanup)
+	}
+	summary := decoded["summary"].(map[string]any)
+	if summary["total"] != float64(2) || summary["errors"] != float64(1) {
+		t.Errorf("unexpected summary: %v", summary)
+	}
+}
+
+func TestReport_EmptySectionsAreArrays(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New("status", "acme").Write(&buf); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	for _, key := range []string{"repos", "local_entries", "cleanup"} {
+		if _, ok := decoded[key].([]any); !ok {
+			t.Errorf("%s should be an empty array, got %v", key, decoded[key])
+		}
+	}
+}
+
+func TestReport_OwnersBreakdown(t *testing.T) {
+	r := New("sync", "")
+	r.AddRepo(model.RepoResult{Name: "acme/api", Owner: "acme", Action: model.ActionCloned})
+	r.AddOwner("acme", "acme", model.Summary{TotalRepos: 1, Cloned: 1})
+	r.AddOwner("octocat", "", model.Summary{TotalRepos: 2})
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if _, ok := decoded["owner"]; ok {
+		t.Errorf("expected no top-level owner when owners are listed, got %v", decoded["owner"])
+	}
+	if repo := decoded["repos"].([]any)[0].(map[string]any); repo["owner"] != "acme" {
+		t.Errorf("unexpected repo owner: %v", repo["owner"])
+	}
+	owners := decoded["owners"].([]any)
+	if len(owners) != 2 {
+		t.Fatalf("expected 2 owners, got %v", owners)
+	}
+	first := owners[0].(map[string]any)
+	if first["owner"] != "acme" || first["directory"] != "acme" || first["summary"].(map[string]any)["cloned"] != float64(1) {
+		t.Errorf("unexpected owner entry: %v", first)
+	}
+	if _, ok := owners[1].(map[string]any)["directory"]; ok {
+		t.Errorf("expected directory omitted for the workspace root: %v", owners[1])
+	}
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/sync"
+)
+
+// RepoLookup confirms that a repository is gone upstream. *github.Client
+// satisfies it.
+type RepoLookup interface {
+	RepoGone(owner, name string) (bool, error)
+}
+
+// MatchOrphans moves unknown git clones whose origin points at one of the
+// owners, but at a repository missing from that owner's inventory, from
+// Unknown to Orphaned: the repository was deleted or transferred upstream.
+// Clones of repositories that are listed but filtered out stay unknown. Owners
+// with a partial inventory, or restricted to teams, are skipped, as their
+// listing omits repositories the owner still has. Each candidate is confirmed
+// with lookup; one that still exists, or cannot be checked, stays unknown. Run
+// MatchRenames first so renamed clones are not reported as orphans.
+func MatchOrphans(root string, result *ScanResult, owners []OwnerRepos, git RepoIdentifier, lookup RepoLookup) {
+	inventories := make(map[string]map[string]bool)
+	for _, o := range owners {
+		if o.Partial || (o.Config != nil && len(o.Config.Teams) > 0) {
+			continue
+		}
+		names := make(map[string]bool, len(o.Inventory))
+		for _, name := range o.Inventory {
+			names[strings.ToLower(name)] = true
+		}
+		inventories[strings.ToLower(o.Owner)] = names
+	}
+	if len(inventories) == 0 {
+		return
+	}
+
+	result.Unknown = slices.DeleteFunc(result.Unknown, func(entry model.LocalEntry) bool {
+		dir := filepath.Join(root, entry.Name)
+		if !isGitClone(dir) {
+			return false
+		}
+		remote, err := git.RemoteURL(dir)
+		if err != nil || remote == "" {
+			return false
+		}
+		origin := sync.ParseRemote(remote)
+		names, ok := inventories[strings.ToLower(origin.Owner)]
+		if !ok || origin.Name == "" || names[strings.ToLower(origin.Name)] {
+			return false
+		}
+		if gone, err := lookup.RepoGone(origin.Owner, origin.Name); err != nil || !gone {
+			return false
+		}
+		result.Orphaned = append(result.Orphaned, model.LocalEntry{
+			Name:           entry.Name,
+			Classification: model.ClassOrphaned,
+			Detail:         fmt.Sprintf("origin %s/%s is no longer in the inventory", origin.Owner, origin.Name),
+		})
+		return true
+	})
+}
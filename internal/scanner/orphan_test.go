@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/config"
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+)
+
+// fakeLookup reports the repositories in gone as deleted upstream.
+type fakeLookup struct {
+	gone map[string]bool
+}
+
+func (f fakeLookup) RepoGone(owner, name string) (bool, error) {
+	return f.gone[owner+"/"+name], nil
+}
+
+func TestMatchOrphans(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"deleted", "private-tool", "fork-of-other", "team-gone", "unlisted"} {
+		makeDotGit(t, dir, name)
+	}
+	result := &ScanResult{
+		Unknown: []model.LocalEntry{
+			{Name: "deleted", Classification: model.ClassUnknown},
+			{Name: "private-tool", Classification: model.ClassUnknown},
+			{Name: "fork-of-other", Classification: model.ClassUnknown},
+			{Name: "team-gone", Classification: model.ClassUnknown},
+			{Name: "unlisted", Classification: model.ClassUnknown},
+			{Name: "notes", Classification: model.ClassUnknown},
+		},
+	}
+	owners := []OwnerRepos{
+		// private-tool is listed but filtered out, so it is not an orphan.
+		{Owner: "Acme", Inventory: []string{"api", "Private-Tool"}, Config: &config.Config{}},
+		{Owner: "globex", Inventory: []string{"web"}, Config: &config.Config{Teams: []string{"platform"}}},
+	}
+	git := fakeIdentifier{remotes: map[string]string{
+		"deleted":       "git@github.com:acme/deleted.git",
+		"private-tool":  "https://github.com/acme/private-tool.git",
+		"fork-of-other": "https://github.com/someone/fork-of-other.git",
+		"team-gone":     "https://github.com/globex/team-gone.git",
+		// Missing from the inventory, but the API still has it.
+		"unlisted": "https://github.com/acme/unlisted.git",
+	}}
+	lookup := fakeLookup{gone: map[string]bool{"acme/deleted": true, "globex/team-gone": true}}
+
+	MatchOrphans(dir, result, owners, git, lookup)
+
+	if len(result.Orphaned) != 1 || result.Orphaned[0].Name != "deleted" || result.Orphaned[0].Classification != model.ClassOrphaned {
+		t.Fatalf("expected only deleted to be orphaned, got %+v", result.Orphaned)
+	}
+	if len(result.Unknown) != 5 {
+		t.Errorf("expected 5 unknown entries left, got %+v", result.Unknown)
+	}
+}
+
+func TestMatchOrphans_SkipsPartialInventories(t *testing.T) {
+	dir := t.TempDir()
+	makeDotGit(t, dir, "private-repo")
+	result := &ScanResult{Unknown: []model.LocalEntry{{Name: "private-repo", Classification: model.ClassUnknown}}}
+	// A public-only user listing or an app installation's grants omit
+	// repositories the owner still has.
+	owners := []OwnerRepos{{Owner: "octocat", Inventory: []string{"public-repo"}, Partial: true, Config: &config.Config{}}}
+	git := fakeIdentifier{remotes: map[string]string{"private-repo": "https://github.com/octocat/private-repo.git"}}
+	lookup := fakeLookup{gone: map[string]bool{"octocat/private-repo": true}}
+
+	MatchOrphans(dir, result, owners, git, lookup)
+
+	if len(result.Orphaned) != 0 || len(result.Unknown) != 1 {
+		t.Errorf("expected the clone to stay unknown, got orphaned %+v", result.Orphaned)
+	}
+}
@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/sync"
+)
+
+// RepoIdentifier reads what identifies a local clone: the GitHub repository
+// ID recorded at clone time and the origin URL. sync.GitRunner satisfies it.
+type RepoIdentifier interface {
+	RepoID(repoDir string) (int64, error)
+	RemoteURL(repoDir string) (string, error)
+}
+
+// MatchRenames matches unknown git clones below root back to missing
+// repositories. A clone matches by the repository ID recorded in it or, when
+// it has no ID, by its origin URL. Each match moves the entry from Unknown to
+// Renamed, with Target set to the repository's path, and removes that path
+// from ManagedMissing so a second copy is not cloned. repos maps each path
+// relative to root to its repository.
+func MatchRenames(root string, result *ScanResult, repos map[string]model.RepoInfo, git RepoIdentifier) {
+	if len(result.ManagedMissing) == 0 {
+		return
+	}
+	byID := make(map[int64]string)
+	for _, path := range result.ManagedMissing {
+		if id := repos[path].ID; id != 0 {
+			byID[id] = path
+		}
+	}
+
+	matched := make(map[string]bool)
+	result.Unknown = slices.DeleteFunc(result.Unknown, func(entry model.LocalEntry) bool {
+		dir := filepath.Join(root, entry.Name)
+		if !isGitClone(dir) {
+			return false
+		}
+		target, how := matchClone(dir, result.ManagedMissing, byID, repos, git)
+		if target == "" || matched[target] {
+			return false
+		}
+		matched[target] = true
+		result.Renamed = append(result.Renamed, model.LocalEntry{
+			Name:           entry.Name,
+			Classification: model.ClassRenamed,
+			Target:         target,
+			Detail:         fmt.Sprintf("%s matches %s", how, target),
+		})
+		return true
+	})
+	result.ManagedMissing = slices.DeleteFunc(result.ManagedMissing, func(path string) bool { return matched[path] })
+}
+
+// matchClone returns the missing path the clone in dir belongs to and how it
+// was matched, or "" when it matches none of them.
+func matchClone(dir string, missing []string, byID map[int64]string, repos map[string]model.RepoInfo, git RepoIdentifier) (string, string) {
+	id, err := git.RepoID(dir)
+	if err != nil {
+		return "", ""
+	}
+	if id != 0 {
+		return byID[id], "repository ID"
+	}
+	remote, err := git.RemoteURL(dir)
+	if err != nil || remote == "" {
+		return "", ""
+	}
+	origin := sync.ParseRemote(remote)
+	if origin.Owner == "" {
+		return "", ""
+	}
+	for _, path := range missing {
+		if origin.SameRepo(sync.ParseRemote(repos[path].CloneURL)) {
+			return path, "origin URL"
+		}
+	}
+	return "", ""
+}
+
+// isGitClone reports whether dir has a .git directory.
+func isGitClone(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil && info.IsDir()
+}
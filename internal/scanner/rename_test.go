@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+)
+
+// fakeIdentifier serves recorded IDs and origin URLs keyed by directory base name.
+type fakeIdentifier struct {
+	ids     map[string]int64
+	remotes map[string]string
+}
+
+func (f fakeIdentifier) RepoID(repoDir string) (int64, error) {
+	return f.ids[filepath.Base(repoDir)], nil
+}
+
+func (f fakeIdentifier) RemoteURL(repoDir string) (string, error) {
+	return f.remotes[filepath.Base(repoDir)], nil
+}
+
+func TestMatchRenames(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"old-api", "old-web", "stranger", "moved"} {
+		makeDotGit(t, dir, name)
+	}
+	repos := map[string]model.RepoInfo{
+		"api": {ID: 1, Name: "api", CloneURL: "https://github.com/acme/api.git"},
+		"web": {ID: 2, Name: "web", CloneURL: "https://github.com/acme/web.git"},
+		"cli": {ID: 3, Name: "cli", CloneURL: "https://github.com/acme/cli.git"},
+	}
+	result := &ScanResult{
+		ManagedMissing: []string{"api", "web", "cli"},
+		Unknown: []model.LocalEntry{
+			{Name: "old-api", Classification: model.ClassUnknown},
+			{Name: "old-web", Classification: model.ClassUnknown},
+			{Name: "stranger", Classification: model.ClassUnknown},
+			{Name: "moved", Classification: model.ClassUnknown},
+			{Name: "not-a-clone", Classification: model.ClassUnknown},
+		},
+	}
+	git := fakeIdentifier{
+		ids: map[string]int64{"old-api": 1, "stranger": 99, "moved": 2},
+		remotes: map[string]string{
+			"old-web":  "git@github.com:acme/web.git",
+			"stranger": "https://github.com/acme/cli.git",
+		},
+	}
+
+	MatchRenames(dir, result, repos, git)
+
+	want := map[string]string{"old-api": "api", "old-web": "web"}
+	if len(result.Renamed) != len(want) {
+		t.Fatalf("expected %d renamed entries, got %+v", len(want), result.Renamed)
+	}
+	for _, e := range result.Renamed {
+		if want[e.Name] != e.Target || e.Classification != model.ClassRenamed {
+			t.Errorf("unexpected renamed entry %+v", e)
+		}
+	}
+	if len(result.ManagedMissing) != 1 || result.ManagedMissing[0] != "cli" {
+		t.Errorf("expected only cli left to clone, got %v", result.ManagedMissing)
+	}
+	// "stranger" has a recorded ID that matches nothing, so its origin is not
+	// consulted; "moved" claims web's ID after old-web already matched it.
+	if len(result.Unknown) != 3 {
+		t.Errorf("expected 3 unknown entries left, got %+v", result.Unknown)
+	}
+}
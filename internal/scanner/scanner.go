@@ -1,9 +1,13 @@
 package scanner
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/config"
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
@@ -19,35 +23,293 @@ type ScanResult struct {
 	Collisions []model.LocalEntry
 	// Unknown are directories that don't match any repo (included or excluded)
 	Unknown []model.LocalEntry
+	// Renamed are unknown clones matched back to a missing repo (see MatchRenames)
+	Renamed []model.LocalEntry
+	// Orphaned are unknown clones of an owner's repos that are no longer in
+	// its inventory (see MatchOrphans)
+	Orphaned []model.LocalEntry
 	// ExcludedButPresent are directories matching excluded repos
 	ExcludedButPresent []model.LocalEntry
+	// SkippedStale are stale repos (see keep_stale_clones) that are not cloned
+	// locally and are therefore not clone candidates
+	SkippedStale []string
 }
 
-// ScanDirectory scans the given directory and classifies each immediate child entry.
-// includedRepos: repos after visibility+exclusion filtering (the ones we want to manage)
-// excludedNames: repo names that were excluded by config
-// allRepoNames: set of all org repo names (before exclusion) for unknown classification
-func ScanDirectory(dir string, includedRepos []model.RepoInfo, excludedNames []string, cfg *config.Config) (*ScanResult, error) {
-	entries, err := os.ReadDir(dir)
+// OwnerRepos is one owner's filtered inventory, as scanned by ScanWorkspace.
+type OwnerRepos struct {
+	Owner     string
+	Directory string // workspace subdirectory holding the owner's clones, including the layout directories; "" for the workspace root
+	Included  []model.RepoInfo
+	Excluded  []string
+	Inventory []string // every repository name listed for the owner, before filtering (see MatchOrphans)
+	Partial   bool     // the listing may omit repositories the owner has, e.g. private ones
+	Config    *config.Config
+}
+
+// ScanWorkspace scans the directory of every owner below root and merges the
+// results. Names in the result are paths relative to root (see
+// model.RepoInfo.Path). Owners that share a directory are scanned together;
+// when two of them have a repository with the same name, the first owner keeps
+// it and the clash is reported as a collision. A directory holding another
+// owner's clones is never classified as an entry of its parent, and a missing
+// owner directory is treated as empty.
+//
+// layout holds the layout directory segments that end every owner's Directory
+// (see config.Config.LayoutDirs); the levels they span are classified by
+// scanLayout.
+func ScanWorkspace(root string, layout []string, owners []OwnerRepos) (*ScanResult, error) {
+	var dirs []string
+	groups := make(map[string][]OwnerRepos)
+	for _, o := range owners {
+		dir := filepath.Clean(o.Directory)
+		if _, ok := groups[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		groups[dir] = append(groups[dir], o)
+	}
+
+	result := &ScanResult{}
+	if len(layout) > 0 {
+		if err := result.scanLayout(root, layout, dirs); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range dirs {
+		reserved := ownerDirsBelow(dir, dirs)
+		included, clashes := claimNames(groups[dir], reserved)
+
+		excludedSet := make(map[string]bool)
+		for _, o := range groups[dir] {
+			for _, name := range o.Excluded {
+				excludedSet[name] = true
+			}
+		}
+		isExcluded := func(name string) bool {
+			if excludedSet[name] {
+				return true
+			}
+			for _, o := range groups[dir] {
+				if o.Config.IsExcluded(name) {
+					return true
+				}
+			}
+			return false
+		}
+
+		entries, err := os.ReadDir(filepath.Join(root, dir))
+		if errors.Is(err, syscall.ENOTDIR) {
+			for _, r := range included {
+				result.Collisions = append(result.Collisions, model.LocalEntry{
+					Name:           r.Path(),
+					Classification: model.ClassCollision,
+					Detail:         "a parent of this path is a file, not a directory",
+					Owner:          r.Owner,
+				})
+			}
+			result.Collisions = append(result.Collisions, clashes...)
+			continue
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading directory: %w", err)
+		}
+		part := scanEntries(filepath.Join(root, dir), entries, included, isExcluded, reserved)
+		result.merge(dir, part)
+		result.Collisions = append(result.Collisions, clashes...)
+	}
+	return result, nil
+}
+
+// scanLayout classifies the entries in the levels that the layout places
+// between each owner's base directory and its owner directory, such as hosts
+// and owners under the ghq layout "{host}/{owner}/{repo}". Entries on the way
+// to an owner directory are skipped; the owner directories themselves are
+// scanned by ScanWorkspace. Every other entry is unknown (see foreignEntries).
+func (r *ScanResult) scanLayout(root string, layout []string, ownerDirs []string) error {
+	// known holds every directory on the way to an owner directory; bases
+	// are the owner directories with the layout directories removed.
+	known := make(map[string]bool)
+	bases := make(map[string]bool)
+	var baseOrder []string
+	for _, dir := range ownerDirs {
+		segments := strings.Split(filepath.ToSlash(dir), "/")
+		if len(segments) < len(layout) {
+			continue
+		}
+		for i := range segments {
+			known[filepath.Join(segments[:i+1]...)] = true
+		}
+		base := filepath.Clean(filepath.Join(segments[:len(segments)-len(layout)]...))
+		if !bases[base] {
+			bases[base] = true
+			baseOrder = append(baseOrder, base)
+		}
+	}
+	for _, base := range baseOrder {
+		if err := r.walkLayout(root, base, layout, 0, known, bases); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkLayout classifies the entries of dir, which fill layout[level].
+func (r *ScanResult) walkLayout(root, dir string, layout []string, level int, known, bases map[string]bool) error {
+	if level == len(layout) {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(root, dir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			return nil
+		}
+		return fmt.Errorf("reading directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		switch {
+		case bases[path]:
+			// Walked on its own as the base of other owners.
+		case known[path]:
+			if err := r.walkLayout(root, path, layout, level+1, known, bases); err != nil {
+				return err
+			}
+		default:
+			r.Unknown = append(r.Unknown, foreignEntries(root, path, layout, level)...)
+		}
+	}
+	return nil
+}
+
+// foreignEntries returns the unknown entries for path, a directory filling
+// layout[level] that leads to no owner directory. A directory in a
+// placeholder level is walked down to the repository level of the template,
+// so that another owner's clones under the ghq layout are reported one by
+// one as host/owner/repo. A directory in a fixed level, a git repository, or
+// a directory with nothing below it is reported itself.
+func foreignEntries(root, path string, layout []string, level int) []model.LocalEntry {
+	self := []model.LocalEntry{{Name: path, Classification: model.ClassUnknown}}
+	if level == len(layout) || !config.IsLayoutPlaceholder(layout[level]) {
+		return self
+	}
+	if info, err := os.Stat(filepath.Join(root, path, ".git")); err == nil && info.IsDir() {
+		return self
+	}
+	entries, err := os.ReadDir(filepath.Join(root, path))
 	if err != nil {
-		return nil, fmt.Errorf("reading directory: %w", err)
+		return self
+	}
+	var found []model.LocalEntry
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || !entry.IsDir() {
+			continue
+		}
+		found = append(found, foreignEntries(root, filepath.Join(path, entry.Name()), layout, level+1)...)
+	}
+	if len(found) == 0 {
+		return self
 	}
+	return found
+}
 
-	// Build lookup maps
+// ownerDirsBelow returns the names of the entries of dir that contain the
+// directory of another owner.
+func ownerDirsBelow(dir string, ownerDirs []string) map[string]bool {
+	reserved := make(map[string]bool)
+	for _, other := range ownerDirs {
+		rel, err := filepath.Rel(dir, other)
+		if err != nil || other == dir || !filepath.IsLocal(rel) {
+			continue
+		}
+		first, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		reserved[first] = true
+	}
+	return reserved
+}
+
+// claimNames merges the included repositories of owners that share one
+// directory. Each name goes to the first owner that has it; later owners'
+// repositories with that name, and repositories named like an owner
+// directory in reserved, are returned as collisions instead. Names are
+// compared without regard to case, since clones that differ only in case
+// cannot coexist on case-insensitive filesystems.
+func claimNames(owners []OwnerRepos, reserved map[string]bool) ([]model.RepoInfo, []model.LocalEntry) {
+	var included []model.RepoInfo
+	var clashes []model.LocalEntry
+	claimed := make(map[string]string)
+	for _, o := range owners {
+		for _, r := range o.Included {
+			key := strings.ToLower(r.Name)
+			switch first, taken := claimed[key]; {
+			case reserved[r.Name]:
+				clashes = append(clashes, model.LocalEntry{
+					Name:           r.Path(),
+					Classification: model.ClassCollision,
+					Detail:         fmt.Sprintf("name clash: %s/%s has the same path as an owner directory; skipped", o.Owner, r.Name),
+					Owner:          o.Owner,
+				})
+			case taken:
+				clashes = append(clashes, model.LocalEntry{
+					Name:           r.Path(),
+					Classification: model.ClassCollision,
+					Detail:         fmt.Sprintf("name clash: %s/%s has the same path as %s's repository; skipped", o.Owner, r.Name, first),
+					Owner:          o.Owner,
+				})
+			default:
+				claimed[key] = o.Owner
+				included = append(included, r)
+			}
+		}
+	}
+	return included, clashes
+}
+
+// merge appends the results of part, scanned in dir, to r with their names
+// made relative to the workspace root.
+func (r *ScanResult) merge(dir string, part *ScanResult) {
+	for _, name := range part.ManagedFound {
+		r.ManagedFound = append(r.ManagedFound, filepath.Join(dir, name))
+	}
+	for _, name := range part.ManagedMissing {
+		r.ManagedMissing = append(r.ManagedMissing, filepath.Join(dir, name))
+	}
+	for _, name := range part.SkippedStale {
+		r.SkippedStale = append(r.SkippedStale, filepath.Join(dir, name))
+	}
+	r.Collisions = appendEntries(r.Collisions, dir, part.Collisions)
+	r.Unknown = appendEntries(r.Unknown, dir, part.Unknown)
+	r.ExcludedButPresent = appendEntries(r.ExcludedButPresent, dir, part.ExcludedButPresent)
+}
+
+// appendEntries appends entries, scanned in dir, to dst with their names
+// made relative to the workspace root.
+func appendEntries(dst []model.LocalEntry, dir string, entries []model.LocalEntry) []model.LocalEntry {
+	for _, entry := range entries {
+		entry.Name = filepath.Join(dir, entry.Name)
+		dst = append(dst, entry)
+	}
+	return dst
+}
+
+// scanEntries classifies the entries of dir. Entries named in reserved hold
+// other owners' clones and are skipped.
+func scanEntries(dir string, entries []os.DirEntry, includedRepos []model.RepoInfo, isExcluded func(string) bool, reserved map[string]bool) *ScanResult {
 	includedMap := make(map[string]model.RepoInfo)
 	for _, r := range includedRepos {
 		includedMap[r.Name] = r
 	}
-	excludedSet := make(map[string]bool)
-	for _, name := range excludedNames {
-		excludedSet[name] = true
-	}
 
 	result := &ScanResult{}
 	localDirs := make(map[string]bool)
 
 	for _, entry := range entries {
 		name := entry.Name()
+		if reserved[name] {
+			continue
+		}
 		// Skip hidden files/directories (starting with .) unless they are a managed repo
 		if len(name) > 0 && name[0] == '.' {
 			if _, ok := includedMap[name]; !ok {
@@ -57,11 +319,12 @@ func ScanDirectory(dir string, includedRepos []model.RepoInfo, excludedNames []s
 		// Only consider directories
 		if !entry.IsDir() {
 			// If a regular file matches a managed repo name, it's a collision
-			if _, ok := includedMap[name]; ok {
+			if r, ok := includedMap[name]; ok {
 				result.Collisions = append(result.Collisions, model.LocalEntry{
 					Name:           name,
 					Classification: model.ClassCollision,
 					Detail:         "path is a file, not a directory",
+					Owner:          r.Owner,
 				})
 			}
 			continue
@@ -69,7 +332,7 @@ func ScanDirectory(dir string, includedRepos []model.RepoInfo, excludedNames []s
 
 		localDirs[name] = true
 
-		if _, ok := includedMap[name]; ok {
+		if r, ok := includedMap[name]; ok {
 			// Check if it's a valid git repo
 			gitDir := filepath.Join(dir, name, ".git")
 			info, err := os.Stat(gitDir)
@@ -78,11 +341,12 @@ func ScanDirectory(dir string, includedRepos []model.RepoInfo, excludedNames []s
 					Name:           name,
 					Classification: model.ClassCollision,
 					Detail:         "directory exists but is not a git repository",
+					Owner:          r.Owner,
 				})
 				continue
 			}
 			result.ManagedFound = append(result.ManagedFound, name)
-		} else if excludedSet[name] || cfg.IsExcluded(name) {
+		} else if isExcluded(name) {
 			result.ExcludedButPresent = append(result.ExcludedButPresent, model.LocalEntry{
 				Name:           name,
 				Classification: model.ClassExcludedButPresent,
@@ -106,11 +370,16 @@ func ScanDirectory(dir string, includedRepos []model.RepoInfo, excludedNames []s
 					break
 				}
 			}
-			if !isCollision {
+			if isCollision {
+				continue
+			}
+			if r.Stale {
+				result.SkippedStale = append(result.SkippedStale, r.Name)
+			} else {
 				result.ManagedMissing = append(result.ManagedMissing, r.Name)
 			}
 		}
 	}
 
-	return result, nil
+	return result
 }
@@ -17,20 +17,25 @@ func makeDotGit(t *testing.T, dir, repoName string) {
 	}
 }
 
-// TestScanDirectory_DotPrefixRepoManaged verifies that a repo whose name starts with a dot
+// scanOwner scans dir as the workspace of a single owner with the given
+// included repositories.
+func scanOwner(t *testing.T, dir string, repos []model.RepoInfo) *ScanResult {
+	t.Helper()
+	result, err := ScanWorkspace(dir, nil, []OwnerRepos{{Owner: "acme", Included: repos, Config: &config.Config{}}})
+	if err != nil {
+		t.Fatalf("ScanWorkspace returned error: %v", err)
+	}
+	return result
+}
+
+// TestScanWorkspace_DotPrefixRepoManaged verifies that a repo whose name starts with a dot
 // (e.g. ".github") is recognised as managed when it exists locally as a valid git clone,
 // and is NOT added to ManagedMissing (which would trigger an erroneous clone attempt).
-func TestScanDirectory_DotPrefixRepoManaged(t *testing.T) {
+func TestScanWorkspace_DotPrefixRepoManaged(t *testing.T) {
 	dir := t.TempDir()
 	makeDotGit(t, dir, ".github")
 
-	repos := []model.RepoInfo{{Name: ".github"}}
-	cfg := &config.Config{}
-
-	result, err := ScanDirectory(dir, repos, nil, cfg)
-	if err != nil {
-		t.Fatalf("ScanDirectory returned error: %v", err)
-	}
+	result := scanOwner(t, dir, []model.RepoInfo{{Name: ".github"}})
 
 	if len(result.ManagedMissing) != 0 {
 		t.Errorf("expected ManagedMissing to be empty, got %v", result.ManagedMissing)
@@ -40,19 +45,13 @@ func TestScanDirectory_DotPrefixRepoManaged(t *testing.T) {
 	}
 }
 
-// TestScanDirectory_DotPrefixRepoMissing verifies that a repo whose name starts with a dot
+// TestScanWorkspace_DotPrefixRepoMissing verifies that a repo whose name starts with a dot
 // is correctly added to ManagedMissing when it does not yet exist locally.
-func TestScanDirectory_DotPrefixRepoMissing(t *testing.T) {
+func TestScanWorkspace_DotPrefixRepoMissing(t *testing.T) {
 	dir := t.TempDir()
 	// deliberately do NOT create .github directory
 
-	repos := []model.RepoInfo{{Name: ".github"}}
-	cfg := &config.Config{}
-
-	result, err := ScanDirectory(dir, repos, nil, cfg)
-	if err != nil {
-		t.Fatalf("ScanDirectory returned error: %v", err)
-	}
+	result := scanOwner(t, dir, []model.RepoInfo{{Name: ".github"}})
 
 	if len(result.ManagedFound) != 0 {
 		t.Errorf("expected ManagedFound to be empty, got %v", result.ManagedFound)
@@ -62,22 +61,225 @@ func TestScanDirectory_DotPrefixRepoMissing(t *testing.T) {
 	}
 }
 
-// TestScanDirectory_NonManagedDotDirIgnored verifies that hidden directories that are NOT
+// TestScanWorkspace_NonManagedDotDirIgnored verifies that hidden directories that are NOT
 // managed repos (e.g. ".git", ".DS_Store") are still ignored and do not appear as Unknown.
-func TestScanDirectory_NonManagedDotDirIgnored(t *testing.T) {
+func TestScanWorkspace_NonManagedDotDirIgnored(t *testing.T) {
 	dir := t.TempDir()
 	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
 		t.Fatalf("failed to create .git dir: %v", err)
 	}
 
-	cfg := &config.Config{}
+	result := scanOwner(t, dir, nil)
+
+	if len(result.Unknown) != 0 {
+		t.Errorf("expected Unknown to be empty (hidden non-managed dirs ignored), got %v", result.Unknown)
+	}
+}
+
+// TestScanWorkspace_StaleRepoSyncedOnlyWhenCloned verifies that a stale repo kept by
+// keep_stale_clones is managed when cloned locally but is never a clone candidate.
+func TestScanWorkspace_StaleRepoSyncedOnlyWhenCloned(t *testing.T) {
+	dir := t.TempDir()
+	makeDotGit(t, dir, "old-cloned")
+
+	repos := []model.RepoInfo{{Name: "old-cloned", Stale: true}, {Name: "old-missing", Stale: true}, {Name: "active"}}
+	result := scanOwner(t, dir, repos)
+
+	if len(result.ManagedFound) != 1 || result.ManagedFound[0] != "old-cloned" {
+		t.Errorf("expected ManagedFound=[old-cloned], got %v", result.ManagedFound)
+	}
+	if len(result.ManagedMissing) != 1 || result.ManagedMissing[0] != "active" {
+		t.Errorf("expected ManagedMissing=[active], got %v", result.ManagedMissing)
+	}
+	if len(result.SkippedStale) != 1 || result.SkippedStale[0] != "old-missing" {
+		t.Errorf("expected SkippedStale=[old-missing], got %v", result.SkippedStale)
+	}
+}
+
+func TestScanWorkspace_OwnerSubdirectories(t *testing.T) {
+	root := t.TempDir()
+	makeDotGit(t, filepath.Join(root, "acme"), "api")
+	makeDotGit(t, root, "personal-notes")
+	if err := os.MkdirAll(filepath.Join(root, "acme", "stray"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	owners := []OwnerRepos{
+		{
+			Owner:     "acme",
+			Directory: "acme",
+			Included:  []model.RepoInfo{{Name: "api", Directory: "acme"}, {Name: "web", Directory: "acme"}},
+			Config:    &config.Config{},
+		},
+		{
+			Owner:    "octocat",
+			Included: []model.RepoInfo{{Name: "personal-notes"}},
+			Config:   &config.Config{},
+		},
+		{
+			Owner:     "globex",
+			Directory: "globex",
+			Included:  []model.RepoInfo{{Name: "site", Directory: "globex"}},
+			Config:    &config.Config{},
+		},
+	}
 
-	result, err := ScanDirectory(dir, nil, nil, cfg)
+	result, err := ScanWorkspace(root, nil, owners)
 	if err != nil {
-		t.Fatalf("ScanDirectory returned error: %v", err)
+		t.Fatalf("ScanWorkspace returned error: %v", err)
 	}
 
-	if len(result.Unknown) != 0 {
-		t.Errorf("expected Unknown to be empty (hidden non-managed dirs ignored), got %v", result.Unknown)
+	wantFound := []string{filepath.Join("acme", "api"), "personal-notes"}
+	if len(result.ManagedFound) != 2 || result.ManagedFound[0] != wantFound[0] || result.ManagedFound[1] != wantFound[1] {
+		t.Errorf("ManagedFound = %v, want %v", result.ManagedFound, wantFound)
+	}
+	wantMissing := []string{filepath.Join("acme", "web"), filepath.Join("globex", "site")}
+	if len(result.ManagedMissing) != 2 || result.ManagedMissing[0] != wantMissing[0] || result.ManagedMissing[1] != wantMissing[1] {
+		t.Errorf("ManagedMissing = %v, want %v", result.ManagedMissing, wantMissing)
+	}
+	// The acme directory belongs to an owner and must not be unknown at the root.
+	if len(result.Unknown) != 1 || result.Unknown[0].Name != filepath.Join("acme", "stray") {
+		t.Errorf("Unknown = %v, want only acme/stray", result.Unknown)
+	}
+}
+
+func TestScanWorkspace_NameClashAcrossOwners(t *testing.T) {
+	root := t.TempDir()
+	makeDotGit(t, root, "docs")
+
+	owners := []OwnerRepos{
+		{Owner: "acme", Included: []model.RepoInfo{{Name: "docs", Owner: "acme"}}, Config: &config.Config{}},
+		{Owner: "globex", Included: []model.RepoInfo{{Name: "Docs", Owner: "globex"}, {Name: "acme"}}, Config: &config.Config{}},
+		{Owner: "initech", Directory: "acme", Config: &config.Config{}},
+	}
+
+	result, err := ScanWorkspace(root, nil, owners)
+	if err != nil {
+		t.Fatalf("ScanWorkspace returned error: %v", err)
+	}
+	if len(result.ManagedFound) != 1 || result.ManagedFound[0] != "docs" {
+		t.Errorf("expected the first owner to keep docs, got ManagedFound=%v", result.ManagedFound)
+	}
+	if len(result.ManagedMissing) != 0 {
+		t.Errorf("expected clashing repos not to be cloned, got ManagedMissing=%v", result.ManagedMissing)
+	}
+	if len(result.Collisions) != 2 {
+		t.Fatalf("expected 2 name clashes, got %v", result.Collisions)
+	}
+	for _, c := range result.Collisions {
+		// Both clashes are globex's repositories that cannot be synced.
+		if c.Classification != model.ClassCollision || c.Detail == "" || c.Owner != "globex" {
+			t.Errorf("unexpected clash entry: %+v", c)
+		}
+	}
+}
+
+func TestScanWorkspace_ExcludedByAnySharingOwner(t *testing.T) {
+	root := t.TempDir()
+	makeDotGit(t, root, "sandbox-1")
+	makeDotGit(t, root, "legacy")
+
+	owners := []OwnerRepos{
+		{Owner: "acme", Excluded: []string{"legacy"}, Config: &config.Config{}},
+		{Owner: "globex", Config: &config.Config{ExcludeRepos: []string{"^sandbox-"}}},
+	}
+
+	result, err := ScanWorkspace(root, nil, owners)
+	if err != nil {
+		t.Fatalf("ScanWorkspace returned error: %v", err)
+	}
+	if len(result.ExcludedButPresent) != 2 || len(result.Unknown) != 0 {
+		t.Errorf("expected both entries excluded-but-present, got excluded=%v unknown=%v", result.ExcludedButPresent, result.Unknown)
+	}
+}
+
+func TestScanWorkspace_GhqLayout(t *testing.T) {
+	root := t.TempDir()
+	acme := filepath.Join("github.com", "acme")
+	makeDotGit(t, filepath.Join(root, acme), "api")
+	makeDotGit(t, filepath.Join(root, "github.com", "stranger"), "tool")
+	makeDotGit(t, filepath.Join(root, "gitlab.com", "bob"), "proj")
+	makeDotGit(t, root, "old-flat-clone")
+	if err := os.MkdirAll(filepath.Join(root, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, acme, "notes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	owners := []OwnerRepos{{
+		Owner:     "acme",
+		Directory: acme,
+		Included:  []model.RepoInfo{{Name: "api", Directory: acme}, {Name: "web", Directory: acme}},
+		Config:    &config.Config{},
+	}}
+
+	result, err := ScanWorkspace(root, []string{config.LayoutHost, config.LayoutOwner}, owners)
+	if err != nil {
+		t.Fatalf("ScanWorkspace returned error: %v", err)
+	}
+	if len(result.ManagedFound) != 1 || result.ManagedFound[0] != filepath.Join(acme, "api") {
+		t.Errorf("ManagedFound = %v", result.ManagedFound)
+	}
+	if len(result.ManagedMissing) != 1 || result.ManagedMissing[0] != filepath.Join(acme, "web") {
+		t.Errorf("ManagedMissing = %v", result.ManagedMissing)
+	}
+
+	want := map[string]bool{
+		filepath.Join("github.com", "stranger", "tool"): true,
+		filepath.Join("gitlab.com", "bob", "proj"):      true,
+		"old-flat-clone":             true,
+		"empty":                      true,
+		filepath.Join(acme, "notes"): true,
+	}
+	if len(result.Unknown) != len(want) {
+		t.Fatalf("Unknown = %v, want %d entries", result.Unknown, len(want))
+	}
+	for _, entry := range result.Unknown {
+		if !want[entry.Name] {
+			t.Errorf("unexpected unknown entry %q", entry.Name)
+		}
+	}
+}
+
+func TestScanWorkspace_FixedLayoutSegment(t *testing.T) {
+	root := t.TempDir()
+	makeDotGit(t, filepath.Join(root, "other", "deep"), "repo")
+
+	owners := []OwnerRepos{{
+		Owner:     "acme",
+		Directory: filepath.Join("src", "acme"),
+		Config:    &config.Config{},
+	}}
+	result, err := ScanWorkspace(root, []string{"src", config.LayoutOwner}, owners)
+	if err != nil {
+		t.Fatalf("ScanWorkspace returned error: %v", err)
+	}
+	if len(result.Unknown) != 1 || result.Unknown[0].Name != "other" {
+		t.Errorf("expected the fixed segment mismatch to be reported as other, got %v", result.Unknown)
+	}
+}
+
+func TestScanWorkspace_OwnerDirectoryBlockedByFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "github.com"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join("github.com", "acme")
+	owners := []OwnerRepos{{
+		Owner:     "acme",
+		Directory: dir,
+		Included:  []model.RepoInfo{{Name: "api", Directory: dir}},
+		Config:    &config.Config{},
+	}}
+	result, err := ScanWorkspace(root, []string{config.LayoutHost, config.LayoutOwner}, owners)
+	if err != nil {
+		t.Fatalf("ScanWorkspace returned error: %v", err)
+	}
+	if len(result.Collisions) != 1 || result.Collisions[0].Name != filepath.Join(dir, "api") {
+		t.Errorf("expected a collision for the blocked repository, got %v", result.Collisions)
+	}
+	if len(result.ManagedMissing) != 0 {
+		t.Errorf("expected no clone candidates, got %v", result.ManagedMissing)
 	}
 }
@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CredentialHelperArg is the first argument ghorgsync is re-invoked with when
+// git runs it as a credential helper (see GitCredentials).
+const CredentialHelperArg = "__git-credential"
+
+// Environment variables through which a git command hands the token and host
+// to the credential helper it starts.
+const (
+	credentialTokenEnv = "GHORGSYNC_GIT_TOKEN"
+	credentialHostEnv  = "GHORGSYNC_GIT_HOST"
+)
+
+// GitCredentials supplies a token to git for HTTPS remotes on one host. Each
+// git command that contacts the remote is pointed at a credential helper,
+// Helper re-invoked with CredentialHelperArg, through its environment alone:
+// nothing is written to git config, and the token travels in the environment
+// rather than on a command line, so it never appears in logged commands.
+type GitCredentials struct {
+	Host   string                 // e.g. "github.com"
+	Token  func() (string, error) // called for every command, so a token can be refreshed
+	Helper string                 // path of the ghorgsync executable
+}
+
+// env returns the environment variables that configure git, appended to any
+// configuration the environment already passes through GIT_CONFIG_COUNT,
+// and the token they carry. Helpers configured elsewhere for the host are
+// reset so the token is used.
+func (c *GitCredentials) env() ([]string, string, error) {
+	token, err := c.Token()
+	if err != nil {
+		return nil, "", err
+	}
+	n, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	key := "credential.https://" + c.Host + ".helper"
+	helper := "!" + shellQuote(c.Helper) + " " + CredentialHelperArg
+	return []string{
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", n, key),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=", n),
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", n+1, key),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", n+1, helper),
+		fmt.Sprintf("GIT_CONFIG_COUNT=%d", n+2),
+		credentialTokenEnv + "=" + token,
+		credentialHostEnv + "=" + c.Host,
+	}, token, nil
+}
+
+// redactToken replaces every occurrence of token in out, so git output that
+// echoes a credential, such as a remote URL with the token embedded, is safe
+// to trace and report.
+func redactToken(out []byte, token string) []byte {
+	if token == "" {
+		return out
+	}
+	return bytes.ReplaceAll(out, []byte(token), []byte("[REDACTED]"))
+}
+
+// shellQuote quotes s for the POSIX shell git runs "!" helpers with.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ServeCredentialHelper answers one request from git to the credential helper
+// configured by GitCredentials. args are the arguments after
+// CredentialHelperArg; only "get" is answered, for HTTPS requests to the
+// configured host, with the token from the environment. "store" and "erase"
+// are ignored, as the token is never kept.
+func ServeCredentialHelper(args []string, r io.Reader, w io.Writer) error {
+	if len(args) != 1 || args[0] != "get" {
+		return nil
+	}
+	attrs := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			attrs[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	token, host := os.Getenv(credentialTokenEnv), os.Getenv(credentialHostEnv)
+	if token == "" || attrs["protocol"] != "https" || !strings.EqualFold(attrs["host"], host) {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "username=x-access-token\npassword=%s\n", token)
+	return err
+}
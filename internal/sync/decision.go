@@ -1,6 +1,7 @@
 package sync
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
@@ -33,32 +34,92 @@ func DecideActions(isDirty bool, currentBranch string, defaultBranch string) Dec
 	return d
 }
 
-// ParseGitStatus parses git status --porcelain output into DirtyFile entries.
-// This is a pure function for testability.
-func ParseGitStatus(output string) []model.DirtyFile {
-	if output == "" {
-		return nil
-	}
-	var files []model.DirtyFile
-	lines := splitLines(output)
-	for _, line := range lines {
-		if len(line) < 4 {
+// ParseGitStatus parses git status --porcelain=v2 --branch -z output into the
+// branch, its upstream tracking state, and DirtyFile entries. Entries are
+// NUL-terminated and paths are never quoted, so names containing spaces,
+// quotes, or non-ASCII characters come through verbatim. This is a pure
+// function for testability.
+func ParseGitStatus(output string) model.RepoStatus {
+	var status model.RepoStatus
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if entry == "" {
 			continue
 		}
-		x := line[0]
-		y := line[1]
-		path := strings.TrimSpace(line[3:])
-
-		f := model.DirtyFile{Path: path}
-		if x != ' ' && x != '?' {
-			f.Staged = true
+		switch entry[0] {
+		case '#':
+			parseStatusHeader(entry, &status)
+		case '1':
+			// 1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>
+			if fields := strings.SplitN(entry, " ", 9); len(fields) == 9 {
+				status.Files = append(status.Files, newDirtyFile(model.ChangeOrdinary, fields[1], fields[8]))
+			}
+		case '2':
+			// 2 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <Xscore> <path>, then the
+			// original path as the next entry.
+			fields := strings.SplitN(entry, " ", 10)
+			if len(fields) != 10 {
+				continue
+			}
+			kind := model.ChangeRenamed
+			if strings.HasPrefix(fields[8], "C") {
+				kind = model.ChangeCopied
+			}
+			f := newDirtyFile(kind, fields[1], fields[9])
+			if i+1 < len(entries) {
+				i++
+				f.OrigPath = entries[i]
+			}
+			status.Files = append(status.Files, f)
+		case 'u':
+			// u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path>
+			if fields := strings.SplitN(entry, " ", 11); len(fields) == 11 {
+				status.Files = append(status.Files, newDirtyFile(model.ChangeUnmerged, fields[1], fields[10]))
+			}
+		case '?':
+			if path, ok := strings.CutPrefix(entry, "? "); ok {
+				status.Files = append(status.Files, model.DirtyFile{
+					Path:     path,
+					Kind:     model.ChangeUntracked,
+					XY:       "??",
+					Unstaged: true,
+				})
+			}
 		}
-		if y != ' ' || x == '?' {
-			f.Unstaged = true
+	}
+	return status
+}
+
+// parseStatusHeader applies one "# branch.*" header line to status.
+func parseStatusHeader(entry string, status *model.RepoStatus) {
+	key, value, _ := strings.Cut(strings.TrimPrefix(entry, "# "), " ")
+	switch key {
+	case "branch.head":
+		// Report a detached HEAD the way rev-parse --abbrev-ref does.
+		if value == "(detached)" {
+			value = "HEAD"
 		}
-		files = append(files, f)
+		status.Branch = value
+	case "branch.upstream":
+		status.Upstream = value
+	case "branch.ab":
+		// "+<ahead> -<behind>"
+		ahead, behind, _ := strings.Cut(value, " ")
+		status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(ahead, "+"))
+		status.Behind, _ = strconv.Atoi(strings.TrimPrefix(behind, "-"))
+	}
+}
+
+// newDirtyFile builds a tracked DirtyFile from its porcelain v2 XY codes,
+// where "." means unchanged.
+func newDirtyFile(kind model.ChangeKind, xy, path string) model.DirtyFile {
+	f := model.DirtyFile{Path: path, Kind: kind, XY: xy}
+	if len(xy) == 2 {
+		f.Staged = xy[0] != '.'
+		f.Unstaged = xy[1] != '.'
 	}
-	return files
+	return f
 }
 
 func splitLines(s string) []string {
@@ -75,3 +136,36 @@ func splitLines(s string) []string {
 	}
 	return lines
 }
+
+// ParseBranchStates parses git for-each-ref output in branchStateFormat into
+// BranchState entries. This is a pure function for testability.
+func ParseBranchStates(output string) []model.BranchState {
+	var branches []model.BranchState
+	for _, line := range splitLines(output) {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 || fields[1] == "" {
+			continue
+		}
+		b := model.BranchState{
+			Name:     fields[1],
+			Current:  fields[0] == "*",
+			Upstream: fields[2],
+		}
+		// The tracking status is "", "gone", "ahead N", "behind N", or
+		// "ahead N, behind M".
+		for part := range strings.SplitSeq(fields[3], ",") {
+			word, count, _ := strings.Cut(strings.TrimSpace(part), " ")
+			n, _ := strconv.Atoi(count)
+			switch word {
+			case "gone":
+				b.Gone = true
+			case "ahead":
+				b.Ahead = n
+			case "behind":
+				b.Behind = n
+			}
+		}
+		branches = append(branches, b)
+	}
+	return branches
+}
@@ -2,6 +2,8 @@ package sync
 
 import (
 	"testing"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
 )
 
 func TestDecideActions_DirtyRepo(t *testing.T) {
@@ -60,82 +62,193 @@ func TestDecideActions_DirtyOnDefaultBranch(t *testing.T) {
 }
 
 func TestParseGitStatus_Empty(t *testing.T) {
-	files := ParseGitStatus("")
-	if len(files) != 0 {
-		t.Errorf("expected 0 files, got %d", len(files))
+	status := ParseGitStatus("")
+	if len(status.Files) != 0 {
+		t.Errorf("expected 0 files, got %d", len(status.Files))
 	}
 }
 
 func TestParseGitStatus_StagedFile(t *testing.T) {
-	// "M  file.go" means staged modification
-	files := ParseGitStatus("M  file.go\n")
-	if len(files) != 1 {
-		t.Fatalf("expected 1 file, got %d", len(files))
+	// "M." means staged modification
+	status := ParseGitStatus("1 M. N... 100644 100644 100644 abc123 def456 file.go\x00")
+	if len(status.Files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(status.Files))
 	}
-	if !files[0].Staged {
+	f := status.Files[0]
+	if !f.Staged {
 		t.Error("expected staged")
 	}
-	if files[0].Unstaged {
+	if f.Unstaged {
 		t.Error("expected not unstaged")
 	}
-	if files[0].Path != "file.go" {
-		t.Errorf("expected file.go, got %s", files[0].Path)
+	if f.Path != "file.go" || f.Kind != model.ChangeOrdinary || f.XY != "M." {
+		t.Errorf("unexpected file: %+v", f)
 	}
 }
 
 func TestParseGitStatus_UnstagedFile(t *testing.T) {
-	// " M file.go" means unstaged modification
-	files := ParseGitStatus(" M file.go\n")
-	if len(files) != 1 {
-		t.Fatalf("expected 1 file, got %d", len(files))
+	// ".M" means unstaged modification
+	status := ParseGitStatus("1 .M N... 100644 100644 100644 abc123 abc123 file.go\x00")
+	if len(status.Files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(status.Files))
 	}
-	if files[0].Staged {
+	if status.Files[0].Staged {
 		t.Error("expected not staged")
 	}
-	if !files[0].Unstaged {
+	if !status.Files[0].Unstaged {
 		t.Error("expected unstaged")
 	}
-	if files[0].Path != "file.go" {
-		t.Errorf("expected file.go, got %s", files[0].Path)
+	if status.Files[0].Path != "file.go" {
+		t.Errorf("expected file.go, got %s", status.Files[0].Path)
 	}
 }
 
 func TestParseGitStatus_UntrackedFile(t *testing.T) {
-	// "?? new.txt" means untracked
-	files := ParseGitStatus("?? new.txt\n")
-	if len(files) != 1 {
-		t.Fatalf("expected 1 file, got %d", len(files))
+	status := ParseGitStatus("? new.txt\x00")
+	if len(status.Files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(status.Files))
 	}
-	if files[0].Staged {
+	f := status.Files[0]
+	if f.Staged {
 		t.Error("untracked should not be staged")
 	}
-	if !files[0].Unstaged {
+	if !f.Unstaged {
 		t.Error("untracked should be unstaged")
 	}
+	if f.Kind != model.ChangeUntracked || f.XY != "??" {
+		t.Errorf("unexpected untracked entry: %+v", f)
+	}
 }
 
 func TestParseGitStatus_MixedFiles(t *testing.T) {
-	input := "M  staged.go\n M unstaged.go\n?? new.txt\n"
-	files := ParseGitStatus(input)
-	if len(files) != 3 {
-		t.Fatalf("expected 3 files, got %d", len(files))
+	input := "1 M. N... 100644 100644 100644 a b staged.go\x00" +
+		"1 .M N... 100644 100644 100644 a a unstaged.go\x00" +
+		"? new.txt\x00"
+	status := ParseGitStatus(input)
+	if len(status.Files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(status.Files))
+	}
+}
+
+func TestParseGitStatus_PathsAreNotQuoted(t *testing.T) {
+	// With -z, paths with spaces, quotes, and non-ASCII characters are verbatim.
+	input := "1 .M N... 100644 100644 100644 a a my file.go\x00" +
+		"? caf\u00e9 \"menu\".txt\x00"
+	status := ParseGitStatus(input)
+	if len(status.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(status.Files))
+	}
+	if status.Files[0].Path != "my file.go" {
+		t.Errorf("expected %q, got %q", "my file.go", status.Files[0].Path)
+	}
+	if status.Files[1].Path != "caf\u00e9 \"menu\".txt" {
+		t.Errorf("unexpected untracked path %q", status.Files[1].Path)
+	}
+}
+
+func TestParseGitStatus_RenamedAndCopied(t *testing.T) {
+	input := "2 R. N... 100644 100644 100644 a a R100 new name.go\x00old name.go\x00" +
+		"2 C. N... 100644 100644 100644 a a C75 copy.go\x00orig.go\x00" +
+		"? after.txt\x00"
+	status := ParseGitStatus(input)
+	want := []model.DirtyFile{
+		{Path: "new name.go", OrigPath: "old name.go", Kind: model.ChangeRenamed, XY: "R.", Staged: true},
+		{Path: "copy.go", OrigPath: "orig.go", Kind: model.ChangeCopied, XY: "C.", Staged: true},
+		{Path: "after.txt", Kind: model.ChangeUntracked, XY: "??", Unstaged: true},
+	}
+	if len(status.Files) != len(want) {
+		t.Fatalf("expected %d files, got %+v", len(want), status.Files)
+	}
+	for i := range want {
+		if status.Files[i] != want[i] {
+			t.Errorf("file %d = %+v, want %+v", i, status.Files[i], want[i])
+		}
+	}
+}
+
+func TestParseGitStatus_Unmerged(t *testing.T) {
+	status := ParseGitStatus("u UU N... 100644 100644 100644 100644 a b c conflict.go\x00")
+	if len(status.Files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(status.Files))
+	}
+	f := status.Files[0]
+	if f.Kind != model.ChangeUnmerged || f.XY != "UU" || f.Path != "conflict.go" {
+		t.Errorf("unexpected unmerged entry: %+v", f)
+	}
+}
+
+func TestParseGitStatus_BranchHeaders(t *testing.T) {
+	input := "# branch.oid 0123abcd\x00" +
+		"# branch.head main\x00" +
+		"# branch.upstream origin/main\x00" +
+		"# branch.ab +2 -5\x00"
+	status := ParseGitStatus(input)
+	if status.Branch != "main" || status.Upstream != "origin/main" {
+		t.Errorf("unexpected branch/upstream: %q/%q", status.Branch, status.Upstream)
+	}
+	if status.Ahead != 2 || status.Behind != 5 {
+		t.Errorf("expected ahead=2 behind=5, got ahead=%d behind=%d", status.Ahead, status.Behind)
+	}
+	if len(status.Files) != 0 {
+		t.Errorf("expected no files, got %+v", status.Files)
+	}
+}
+
+func TestParseGitStatus_DetachedHead(t *testing.T) {
+	status := ParseGitStatus("# branch.oid 0123abcd\x00# branch.head (detached)\x00")
+	if status.Branch != "HEAD" {
+		t.Errorf("expected detached HEAD to be reported as HEAD, got %q", status.Branch)
+	}
+	if status.Upstream != "" {
+		t.Errorf("expected no upstream, got %q", status.Upstream)
 	}
 }
 
 func TestParseGitStatus_UninitializedSubmodule(t *testing.T) {
-	// An uninitialized submodule shows as "?? submodule-dir/" in git status --porcelain.
+	// An uninitialized submodule shows as an untracked "submodule-dir/" in git status.
 	// Once initialized via `git submodule update --init`, it disappears from status output.
-	files := ParseGitStatus("?? vendor/some-lib/\n")
-	if len(files) != 1 {
-		t.Fatalf("expected 1 file, got %d", len(files))
+	status := ParseGitStatus("? vendor/some-lib/\x00")
+	if len(status.Files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(status.Files))
 	}
-	if files[0].Staged {
+	if status.Files[0].Staged {
 		t.Error("uninitialized submodule should not be staged")
 	}
-	if !files[0].Unstaged {
+	if !status.Files[0].Unstaged {
 		t.Error("uninitialized submodule should be unstaged")
 	}
-	if files[0].Path != "vendor/some-lib/" {
-		t.Errorf("expected vendor/some-lib/, got %s", files[0].Path)
+	if status.Files[0].Path != "vendor/some-lib/" {
+		t.Errorf("expected vendor/some-lib/, got %s", status.Files[0].Path)
+	}
+}
+
+func TestParseBranchStates(t *testing.T) {
+	output := " \x00feature\x00\x00\n" +
+		"*\x00main\x00origin/main\x00ahead 2, behind 1\n" +
+		" \x00old\x00origin/old\x00gone\n" +
+		" \x00release\x00origin/release\x00behind 4\n" +
+		" \x00synced\x00origin/synced\x00\n"
+
+	got := ParseBranchStates(output)
+	want := []model.BranchState{
+		{Name: "feature"},
+		{Name: "main", Current: true, Upstream: "origin/main", Ahead: 2, Behind: 1},
+		{Name: "old", Upstream: "origin/old", Gone: true},
+		{Name: "release", Upstream: "origin/release", Behind: 4},
+		{Name: "synced", Upstream: "origin/synced"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d branches, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("branch %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseBranchStates_Empty(t *testing.T) {
+	if got := ParseBranchStates(""); len(got) != 0 {
+		t.Fatalf("expected no branches, got %+v", got)
 	}
 }
@@ -1,16 +1,28 @@
 package sync
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
 )
 
 // Engine orchestrates per-repo sync operations.
 type Engine struct {
-	Git     GitRunner
-	BaseDir string
-	Verbose bool
+	Git      GitRunner
+	BaseDir  string
+	Verbose  bool
+	Protocol string // clone protocol: "https" (default) or "ssh"
+	// MigrateDefaultBranch renames a local branch left behind by a default
+	// branch rename upstream, instead of only reporting it.
+	MigrateDefaultBranch bool
+	// Offline skips fetch, pull, and their retries: ProcessRepo reports the
+	// clone's local state only, as StatusRepo does.
+	Offline bool
+
+	sleep func(time.Duration) // waits between retries; nil uses time.Sleep
 }
 
 // NewEngine creates a new sync engine.
@@ -35,69 +47,295 @@ func NewEngine(baseDir string, verbosity int, logf func(string, ...any), tracef
 	}
 }
 
+// SetCredentials supplies creds to every git command that contacts the
+// remote: clone, fetch, pull, and submodule update.
+func (e *Engine) SetCredentials(creds *GitCredentials) {
+	runner := e.Git
+	if logging, ok := runner.(*LoggingGitRunner); ok {
+		runner = logging.next
+	}
+	if exec, ok := runner.(*ExecGitRunner); ok {
+		exec.credentials = creds
+	}
+}
+
+// ObserveGit registers fn to receive a record of every git operation the
+// engine runs, independent of verbosity.
+func (e *Engine) ObserveGit(fn func(GitCommandRecord)) {
+	logging, ok := e.Git.(*LoggingGitRunner)
+	if !ok {
+		logging = &LoggingGitRunner{next: e.Git}
+		e.Git = logging
+	}
+	logging.Observe(fn)
+}
+
 // CloneRepo clones a missing repository.
 func (e *Engine) CloneRepo(repo model.RepoInfo) model.RepoResult {
-	dest := filepath.Join(e.BaseDir, repo.Name)
-	err := e.Git.Clone(repo.CloneURL, dest)
+	dest := filepath.Join(e.BaseDir, repo.Path())
+	err := e.retry(func() error { return e.Git.Clone(e.cloneURL(repo), dest) })
 	if err != nil {
 		return model.RepoResult{
-			Name:          repo.Name,
+			Name:          repo.Path(),
+			Owner:         repo.Owner,
 			Action:        model.ActionCloneError,
 			DefaultBranch: repo.DefaultBranch,
 			Error:         err,
+			ErrorCategory: ErrorCategoryOf(err),
 		}
 	}
+	// A missing ID only prevents rename detection, so failing to record it
+	// does not fail the clone.
+	if repo.ID != 0 {
+		_ = e.Git.SetRepoID(dest, repo.ID)
+	}
 	return model.RepoResult{
-		Name:          repo.Name,
+		Name:          repo.Path(),
+		Owner:         repo.Owner,
 		Action:        model.ActionCloned,
 		DefaultBranch: repo.DefaultBranch,
 	}
 }
 
-// ProcessRepo audits and syncs an existing local repository.
-func (e *Engine) ProcessRepo(repo model.RepoInfo) model.RepoResult {
-	repoDir := filepath.Join(e.BaseDir, repo.Name)
-	result := model.RepoResult{
-		Name:          repo.Name,
+// cloneURL returns the URL a repository is cloned from, honouring the
+// configured protocol. It falls back to the HTTPS URL when the inventory has
+// no SSH URL for the repository.
+func (e *Engine) cloneURL(repo model.RepoInfo) string {
+	if e.Protocol == "ssh" && repo.SSHURL != "" {
+		return repo.SSHURL
+	}
+	return repo.CloneURL
+}
+
+// recordRepoID stores repo's GitHub ID in a clone that has none yet, such as
+// a clone made before IDs were recorded. Failures are ignored: a missing ID
+// only prevents rename detection.
+func (e *Engine) recordRepoID(repoDir string, repo model.RepoInfo) {
+	if repo.ID == 0 {
+		return
+	}
+	if id, err := e.Git.RepoID(repoDir); err == nil && id == 0 {
+		_ = e.Git.SetRepoID(repoDir, repo.ID)
+	}
+}
+
+// AdoptRename moves the clone at oldPath, relative to BaseDir, to repo's path
+// after the repository was renamed on GitHub. origin is pointed at repo's URL,
+// keeping the protocol the clone already uses, and repo's ID is recorded. It
+// fails without changing anything if repo's path already exists.
+func (e *Engine) AdoptRename(oldPath string, repo model.RepoInfo) error {
+	src := filepath.Join(e.BaseDir, oldPath)
+	dest := filepath.Join(e.BaseDir, repo.Path())
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("%s already exists", repo.Path())
+	}
+
+	url := repo.CloneURL
+	if remote, err := e.Git.RemoteURL(src); err == nil && ParseRemote(remote).Protocol == "ssh" && repo.SSHURL != "" {
+		url = repo.SSHURL
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dest); err != nil {
+		return err
+	}
+	if err := e.Git.SetRemoteURL(dest, url); err != nil {
+		return err
+	}
+	if repo.ID != 0 {
+		return e.Git.SetRepoID(dest, repo.ID)
+	}
+	return nil
+}
+
+// LocalWork reads the branches and stash entries of the clone at path,
+// relative to BaseDir, without fetching or changing it. It is used for clones
+// that are not managed, such as orphans, to tell whether they hold unpushed
+// work (see model.RepoResult.HasUnpushedWork).
+func (e *Engine) LocalWork(path string) model.RepoResult {
+	result := model.RepoResult{Name: path}
+	e.collectLocalWork(filepath.Join(e.BaseDir, path), &result, nil)
+	return result
+}
+
+// previousDefaultBranch returns the former default branch when the default
+// branch was renamed upstream: origin/HEAD still names a branch other than the
+// default, and a local branch of that name tracks it and its upstream is gone.
+// existed reports whether a local branch of the new name exists as well. It
+// returns "" otherwise, including when branches, the clone's local branches,
+// could not be read.
+func (e *Engine) previousDefaultBranch(repoDir string, repo model.RepoInfo, branches []model.BranchState) (prev string, existed bool) {
+	if branches == nil {
+		return "", false
+	}
+	head, err := e.Git.RemoteHead(repoDir)
+	if err != nil || head == "" || repo.DefaultBranch == "" || head == repo.DefaultBranch {
+		return "", false
+	}
+	stale := false
+	for _, b := range branches {
+		switch b.Name {
+		case repo.DefaultBranch:
+			existed = true
+		case head:
+			stale = b.Gone && b.Upstream == "origin/"+head
+		}
+	}
+	if !stale {
+		return "", false
+	}
+	return head, existed
+}
+
+// checkRemote records origin's URL on result and flags clones whose origin
+// uses a different protocol than the configured one. It returns false, with
+// result set to ActionRemoteMismatch, when origin does not point at the
+// repository's expected owner/name. Nothing is ever rewritten. Failing to read
+// origin is non-fatal and skips the checks.
+func (e *Engine) checkRemote(repoDir string, repo model.RepoInfo, result *model.RepoResult) bool {
+	remote, err := e.Git.RemoteURL(repoDir)
+	if err != nil || remote == "" {
+		return true
+	}
+	result.RemoteURL = remote
+	actual := ParseRemote(remote)
+
+	expectedProtocol := e.Protocol
+	if expectedProtocol == "" {
+		expectedProtocol = "https"
+	}
+	result.ProtocolMismatch = actual.Protocol != "" && actual.Protocol != expectedProtocol
+
+	expected := ParseRemote(repo.CloneURL)
+	if expected.Owner == "" || actual.SameRepo(expected) {
+		return true
+	}
+	result.Action = model.ActionRemoteMismatch
+	result.Error = fmt.Errorf("origin %s does not point to %s/%s", remote, expected.Owner, expected.Name)
+	return false
+}
+
+// collectLocalWork records the state of every local branch and the stash
+// count, so work that exists only in this clone can be reported. branches, when
+// not nil, were already read and are still current; otherwise they are read
+// here. Failures are non-fatal: the data is informational and simply omitted.
+func (e *Engine) collectLocalWork(repoDir string, result *model.RepoResult, branches []model.BranchState) {
+	if branches == nil {
+		branches, _ = e.Git.BranchStates(repoDir)
+	}
+	if branches != nil {
+		for i, b := range branches {
+			if b.Upstream != "" && !b.Gone {
+				continue
+			}
+			// A branch without a live upstream may still be fully pushed, for
+			// example merged before its remote branch was deleted. When the
+			// commits cannot be counted the branch stays flagged.
+			if n, err := e.Git.UnpublishedCommits(repoDir, b.Name); err == nil {
+				branches[i].Unpublished = n
+				branches[i].Published = n == 0
+			}
+		}
+		result.Branches = branches
+	}
+	if stashes, err := e.Git.StashCount(repoDir); err == nil {
+		result.Stashes = stashes
+	}
+}
+
+// checkHeadState flags a repository that git is part-way through changing: an
+// in-progress merge, rebase, cherry-pick, revert, or bisect, or a detached
+// HEAD. It returns false, with result set to ActionInProgress or
+// ActionDetachedHead, when the repository must be left exactly as it is.
+// Failing to read the git directory is non-fatal and skips the operation check.
+func (e *Engine) checkHeadState(repoDir string, result *model.RepoResult) bool {
+	if operation, err := e.Git.InProgressOperation(repoDir); err == nil && operation != "" {
+		result.Action = model.ActionInProgress
+		result.Operation = operation
+		return false
+	}
+	// rev-parse --abbrev-ref reports a detached HEAD as the literal "HEAD".
+	if result.CurrentBranch == "HEAD" {
+		result.Action = model.ActionDetachedHead
+		return false
+	}
+	return true
+}
+
+// fail records a failed step on result.
+func fail(result *model.RepoResult, action model.RepoAction, err error) {
+	result.Action = action
+	result.Error = err
+	result.ErrorCategory = ErrorCategoryOf(err)
+}
+
+// ProcessRepo audits and syncs an existing local repository. Local branches
+// and stashes are collected whatever the outcome, since a failed fetch or a
+// suspect origin is when unpushed work is most at risk.
+func (e *Engine) ProcessRepo(repo model.RepoInfo) (result model.RepoResult) {
+	repoDir := filepath.Join(e.BaseDir, repo.Path())
+	result = model.RepoResult{
+		Name:          repo.Path(),
+		Owner:         repo.Owner,
 		DefaultBranch: repo.DefaultBranch,
 	}
+	if e.Offline {
+		return e.StatusRepo(repo)
+	}
+	// The local branches are read once and reused for the local work report,
+	// unless a checkout, rename, or pull below changes them.
+	var branches []model.BranchState
+	defer func() { e.collectLocalWork(repoDir, &result, branches) }()
 
-	// Always fetch (safe operation)
-	if err := e.Git.Fetch(repoDir); err != nil {
-		result.Action = model.ActionFetchError
-		result.Error = err
+	if !e.checkRemote(repoDir, repo, &result) {
 		return result
 	}
+	e.recordRepoID(repoDir, repo)
 
-	// Initialize and update submodules to avoid false dirty state from
-	// uninitialized submodule directories.
-	if err := e.Git.SubmoduleUpdate(repoDir); err != nil {
-		result.Action = model.ActionSubmoduleError
-		result.Error = err
+	// Always fetch (safe operation)
+	if err := e.retry(func() error { return e.Git.Fetch(repoDir) }); err != nil {
+		fail(&result, model.ActionFetchError, err)
 		return result
 	}
 
-	// Get current branch
-	branch, err := e.Git.CurrentBranch(repoDir)
+	// Read the branch, its upstream tracking state, and changed files at once
+	status, err := e.Git.Status(repoDir)
 	if err != nil {
-		result.Action = model.ActionFetchError
-		result.Error = err
+		fail(&result, model.ActionFetchError, err)
 		return result
 	}
-	result.CurrentBranch = branch
-	result.BranchDrift = branch != repo.DefaultBranch
+	result.CurrentBranch = status.Branch
 
-	// Check dirty state
-	dirty, files, err := e.Git.IsDirty(repoDir)
-	if err != nil {
-		result.Action = model.ActionFetchError
-		result.Error = err
+	// Never touch a repository that is mid-operation or detached, not even
+	// its submodules.
+	if !e.checkHeadState(repoDir, &result) {
 		return result
 	}
+	result.BranchDrift = status.Branch != repo.DefaultBranch
+	branches, _ = e.Git.BranchStates(repoDir)
+	result.PreviousDefault, result.DefaultExisted = e.previousDefaultBranch(repoDir, repo, branches)
 
-	if dirty {
+	// Initialize and update submodules to avoid false dirty state from
+	// uninitialized submodule directories.
+	if err := e.Git.SubmoduleUpdate(repoDir); err != nil {
+		fail(&result, model.ActionSubmoduleError, err)
+		return result
+	}
+
+	// Uninitialized submodule directories appear as untracked files until the
+	// update above, so re-read a status that looked dirty.
+	if len(status.Files) > 0 {
+		if status, err = e.Git.Status(repoDir); err != nil {
+			fail(&result, model.ActionFetchError, err)
+			return result
+		}
+	}
+
+	if len(status.Files) > 0 {
 		result.Action = model.ActionDirty
-		result.DirtyFiles = files
+		result.DirtyFiles = status.Files
 		// Get diff stats
 		adds, dels, _ := e.Git.DiffStats(repoDir)
 		result.Additions = adds
@@ -106,20 +344,57 @@ func (e *Engine) ProcessRepo(repo model.RepoInfo) model.RepoResult {
 	}
 
 	// Clean repo: checkout default branch if needed, then pull
-	if result.BranchDrift {
+	ahead, behind, hasUpstream := status.Ahead, status.Behind, status.Upstream != ""
+	if result.PreviousDefault != "" && !result.DefaultExisted {
+		if !e.MigrateDefaultBranch {
+			// Checking out the new default would leave the old branch behind,
+			// tracking a deleted remote branch, so change nothing.
+			result.Action = model.ActionDefaultBranchRenamed
+			return result
+		}
+		if err := e.Git.RenameDefaultBranch(repoDir, result.PreviousDefault, repo.DefaultBranch); err != nil {
+			fail(&result, model.ActionCheckoutError, err)
+			return result
+		}
+		result.BranchMigrated = true
+		branches = nil
+		if status.Branch == result.PreviousDefault {
+			result.CurrentBranch = repo.DefaultBranch
+			// The status describes the old branch's deleted upstream.
+			ahead, behind, err = e.Git.AheadBehind(repoDir)
+			hasUpstream = err == nil
+		}
+	}
+	if result.CurrentBranch != repo.DefaultBranch {
 		if err := e.Git.Checkout(repoDir, repo.DefaultBranch); err != nil {
-			result.Action = model.ActionCheckoutError
-			result.Error = err
+			fail(&result, model.ActionCheckoutError, err)
 			return result
 		}
 		result.CurrentBranch = repo.DefaultBranch
+		branches = nil
+		// The status describes the branch that was just left.
+		ahead, behind, err = e.Git.AheadBehind(repoDir)
+		hasUpstream = err == nil
+	}
+
+	// A branch with both local-only and upstream-only commits cannot be
+	// fast-forwarded; report the divergence rather than a pull error. Branches
+	// without an upstream are left for the pull to report.
+	if hasUpstream && ahead > 0 && behind > 0 {
+		result.Action = model.ActionDiverged
+		result.Ahead = ahead
+		result.Behind = behind
+		return result
 	}
 
 	// Pull with ff-only
-	changed, err := e.Git.PullFF(repoDir)
+	var changed bool
+	err = e.retry(func() (err error) {
+		changed, err = e.Git.PullFF(repoDir)
+		return err
+	})
 	if err != nil {
-		result.Action = model.ActionPullError
-		result.Error = err
+		fail(&result, model.ActionPullError, err)
 		return result
 	}
 
@@ -129,8 +404,27 @@ func (e *Engine) ProcessRepo(repo model.RepoInfo) model.RepoResult {
 	_ = e.Git.SubmoduleUpdate(repoDir)
 
 	result.Updated = changed
-
 	if changed {
+		branches = nil
+	}
+
+	// The new default already existed next to the old one, so the old branch
+	// is only deleted when nothing on it would be lost.
+	if result.PreviousDefault != "" && result.DefaultExisted && e.MigrateDefaultBranch {
+		deleted, err := e.Git.RetireDefaultBranch(repoDir, result.PreviousDefault, repo.DefaultBranch)
+		if err != nil {
+			fail(&result, model.ActionCheckoutError, err)
+			return result
+		}
+		result.BranchMigrated = deleted
+		if deleted {
+			branches = nil
+		}
+	}
+
+	if result.PreviousDefault != "" {
+		result.Action = model.ActionDefaultBranchRenamed
+	} else if changed {
 		if result.BranchDrift {
 			result.Action = model.ActionBranchDrift
 		} else {
@@ -148,49 +442,50 @@ func (e *Engine) ProcessRepo(repo model.RepoInfo) model.RepoResult {
 }
 
 // StatusRepo reads the current state of a repository without modifying it.
-// It returns ActionDirty if the working tree is dirty, ActionBranchDrift if
+// It returns ActionRemoteMismatch if origin points at an unexpected
+// repository, ActionInProgress if a merge, rebase, cherry-pick, revert, or
+// bisect is in progress, ActionDetachedHead if HEAD is not on a branch,
+// ActionDirty if the working tree is dirty, ActionBranchDrift if
 // the repo is on a non-default branch (and clean), or ActionAlreadyCurrent
 // if the repo is clean and on the default branch.
-func (e *Engine) StatusRepo(repo model.RepoInfo) model.RepoResult {
-	repoDir := filepath.Join(e.BaseDir, repo.Name)
-	result := model.RepoResult{
-		Name:          repo.Name,
+func (e *Engine) StatusRepo(repo model.RepoInfo) (result model.RepoResult) {
+	repoDir := filepath.Join(e.BaseDir, repo.Path())
+	result = model.RepoResult{
+		Name:          repo.Path(),
+		Owner:         repo.Owner,
 		DefaultBranch: repo.DefaultBranch,
 	}
+	defer e.collectLocalWork(repoDir, &result, nil)
 
-	// Get current branch
-	branch, err := e.Git.CurrentBranch(repoDir)
-	if err != nil {
-		result.Action = model.ActionFetchError
-		result.Error = err
+	if !e.checkRemote(repoDir, repo, &result) {
 		return result
 	}
-	result.CurrentBranch = branch
-	result.BranchDrift = branch != repo.DefaultBranch
 
-	// Check dirty state
-	dirty, files, err := e.Git.IsDirty(repoDir)
+	// Read the branch and changed files at once
+	status, err := e.Git.Status(repoDir)
 	if err != nil {
-		result.Action = model.ActionFetchError
-		result.Error = err
+		fail(&result, model.ActionFetchError, err)
+		return result
+	}
+	result.CurrentBranch = status.Branch
+
+	if !e.checkHeadState(repoDir, &result) {
 		return result
 	}
+	result.BranchDrift = status.Branch != repo.DefaultBranch
 
-	if dirty {
+	if len(status.Files) > 0 {
 		result.Action = model.ActionDirty
-		result.DirtyFiles = files
+		result.DirtyFiles = status.Files
 		// Get colorized status output for display; non-fatal if unavailable
 		// since the dirty state is already captured in DirtyFiles.
 		statusOut, _ := e.Git.StatusShort(repoDir)
 		result.StatusOutput = statusOut
-		return result
-	}
-
-	if result.BranchDrift {
+	} else if result.BranchDrift {
 		result.Action = model.ActionBranchDrift
-		return result
+	} else {
+		result.Action = model.ActionAlreadyCurrent
 	}
 
-	result.Action = model.ActionAlreadyCurrent
 	return result
 }
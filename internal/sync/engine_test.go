@@ -2,6 +2,10 @@ package sync
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
@@ -11,30 +15,95 @@ import (
 type mockGitRunner struct {
 	currentBranch string
 	branchErr     error
-	dirty         bool
 	dirtyFiles    []model.DirtyFile
 	dirtyErr      error
 	statusOutput  string
 	statusErr     error
+
+	ahead, behind  int
+	aheadBehindErr error
+	branches       []model.BranchState
+	stashes        int
+	operation      string
+	unpublished    map[string]int // commits per branch on no remote-tracking branch
+	unpublishedErr error
+
+	repoID     int64 // ID reported by RepoID
+	recordedID int64 // last ID passed to SetRepoID
+	remoteSet  string
+
+	remoteHead      string // branch origin/HEAD points at
+	checkedOut      string // last branch passed to Checkout
+	renamedBranches string // "from->to" passed to RenameDefaultBranch
+	unmerged        bool   // RetireDefaultBranch finds commits of its own
+	retiredBranch   string // branch deleted by RetireDefaultBranch
 }
 
 func (m *mockGitRunner) Clone(url, dest string) error               { return nil }
 func (m *mockGitRunner) Fetch(repoDir string) error                 { return nil }
 func (m *mockGitRunner) SubmoduleUpdate(repoDir string) error       { return nil }
-func (m *mockGitRunner) Checkout(repoDir, branch string) error      { return nil }
 func (m *mockGitRunner) PullFF(repoDir string) (bool, error)        { return false, nil }
 func (m *mockGitRunner) RemoteURL(repoDir string) (string, error)   { return "", nil }
 func (m *mockGitRunner) DiffStats(repoDir string) (int, int, error) { return 0, 0, nil }
-func (m *mockGitRunner) CurrentBranch(repoDir string) (string, error) {
-	return m.currentBranch, m.branchErr
+func (m *mockGitRunner) Checkout(repoDir, branch string) error {
+	m.checkedOut = branch
+	return nil
+}
+func (m *mockGitRunner) RemoteHead(repoDir string) (string, error) { return m.remoteHead, nil }
+func (m *mockGitRunner) RenameDefaultBranch(repoDir, from, to string) error {
+	m.renamedBranches = from + "->" + to
+	return nil
 }
-func (m *mockGitRunner) IsDirty(repoDir string) (bool, []model.DirtyFile, error) {
-	return m.dirty, m.dirtyFiles, m.dirtyErr
+
+func (m *mockGitRunner) RetireDefaultBranch(repoDir, from, to string) (bool, error) {
+	if m.unmerged {
+		return false, nil
+	}
+	m.retiredBranch = from
+	return true, nil
+}
+
+// Status reports the mock's branch and files. The branch has an upstream
+// unless aheadBehindErr is set.
+func (m *mockGitRunner) Status(repoDir string) (model.RepoStatus, error) {
+	if m.branchErr != nil {
+		return model.RepoStatus{}, m.branchErr
+	}
+	if m.dirtyErr != nil {
+		return model.RepoStatus{}, m.dirtyErr
+	}
+	status := model.RepoStatus{Branch: m.currentBranch, Ahead: m.ahead, Behind: m.behind, Files: m.dirtyFiles}
+	if m.aheadBehindErr == nil {
+		status.Upstream = "origin/" + m.currentBranch
+	}
+	return status, nil
 }
 func (m *mockGitRunner) StatusShort(repoDir string) (string, error) {
 	return m.statusOutput, m.statusErr
 }
 func (m *mockGitRunner) IgnoredPaths(repoDir string) ([]string, error) { return nil, nil }
+func (m *mockGitRunner) AheadBehind(repoDir string) (int, int, error) {
+	return m.ahead, m.behind, m.aheadBehindErr
+}
+func (m *mockGitRunner) BranchStates(repoDir string) ([]model.BranchState, error) {
+	return slices.Clone(m.branches), nil // callers fill in fields, as on a fresh read
+}
+func (m *mockGitRunner) UnpublishedCommits(repoDir, branch string) (int, error) {
+	return m.unpublished[branch], m.unpublishedErr
+}
+func (m *mockGitRunner) StashCount(repoDir string) (int, error) { return m.stashes, nil }
+func (m *mockGitRunner) InProgressOperation(repoDir string) (string, error) {
+	return m.operation, nil
+}
+func (m *mockGitRunner) SetRemoteURL(repoDir, url string) error {
+	m.remoteSet = url
+	return nil
+}
+func (m *mockGitRunner) RepoID(repoDir string) (int64, error) { return m.repoID, nil }
+func (m *mockGitRunner) SetRepoID(repoDir string, id int64) error {
+	m.recordedID = id
+	return nil
+}
 
 func TestStatusRepo_CleanOnDefaultBranch(t *testing.T) {
 	eng := &Engine{
@@ -54,7 +123,6 @@ func TestStatusRepo_DirtyRepo(t *testing.T) {
 	eng := &Engine{
 		Git: &mockGitRunner{
 			currentBranch: "main",
-			dirty:         true,
 			dirtyFiles:    []model.DirtyFile{{Path: "file.go", Unstaged: true}},
 			statusOutput:  " M file.go\n",
 		},
@@ -96,7 +164,6 @@ func TestStatusRepo_DirtyWithBranchDrift(t *testing.T) {
 	eng := &Engine{
 		Git: &mockGitRunner{
 			currentBranch: "feature",
-			dirty:         true,
 			dirtyFiles:    []model.DirtyFile{{Path: "file.go", Staged: true}},
 			statusOutput:  "M  file.go\n",
 		},
@@ -148,3 +215,612 @@ func TestStatusRepo_DirtyCheckError(t *testing.T) {
 		t.Errorf("expected ActionFetchError, got %v", result.Action)
 	}
 }
+
+func TestProcessRepo_ReportsProtocolMismatch(t *testing.T) {
+	eng := &Engine{
+		Git:      &remoteMockGitRunner{mockGitRunner: mockGitRunner{currentBranch: "main"}, remote: "git@github.com:acme/repo.git"},
+		BaseDir:  "/tmp",
+		Protocol: "https",
+	}
+	result := eng.ProcessRepo(sampleRepo())
+
+	if !result.ProtocolMismatch {
+		t.Error("expected ProtocolMismatch for ssh origin with https clone_protocol")
+	}
+	if result.RemoteURL != "git@github.com:acme/repo.git" {
+		t.Errorf("RemoteURL = %q", result.RemoteURL)
+	}
+	if result.Action != model.ActionAlreadyCurrent {
+		t.Errorf("expected processing to continue, got %v", result.Action)
+	}
+}
+
+func TestProcessRepo_MatchingProtocol(t *testing.T) {
+	eng := &Engine{
+		Git:      &remoteMockGitRunner{mockGitRunner: mockGitRunner{currentBranch: "main"}, remote: "git@github.com:acme/repo.git"},
+		BaseDir:  "/tmp",
+		Protocol: "ssh",
+	}
+	if eng.ProcessRepo(sampleRepo()).ProtocolMismatch {
+		t.Error("expected no ProtocolMismatch when origin uses the configured protocol")
+	}
+}
+
+func TestCloneRepo_UsesConfiguredProtocol(t *testing.T) {
+	git := &remoteMockGitRunner{}
+	eng := &Engine{Git: git, BaseDir: "/tmp", Protocol: "ssh"}
+	eng.CloneRepo(sampleRepo())
+	if git.clonedURL != "git@github.com:acme/repo.git" {
+		t.Errorf("cloned from %q, want ssh URL", git.clonedURL)
+	}
+
+	eng.Protocol = "https"
+	eng.CloneRepo(sampleRepo())
+	if git.clonedURL != "https://github.com/acme/repo.git" {
+		t.Errorf("cloned from %q, want https URL", git.clonedURL)
+	}
+}
+
+func TestCloneRepo_IntoOwnerDirectory(t *testing.T) {
+	git := &remoteMockGitRunner{}
+	eng := &Engine{Git: git, BaseDir: "/tmp"}
+	repo := sampleRepo()
+	repo.Owner = "acme"
+	repo.Directory = "acme"
+
+	result := eng.CloneRepo(repo)
+	if want := filepath.Join("/tmp", "acme", "repo"); git.clonedDest != want {
+		t.Errorf("cloned into %q, want %q", git.clonedDest, want)
+	}
+	if result.Name != filepath.Join("acme", "repo") || result.Owner != "acme" {
+		t.Errorf("unexpected result name/owner: %q/%q", result.Name, result.Owner)
+	}
+}
+
+// remoteMockGitRunner extends mockGitRunner with a configurable origin URL and
+// records the URL and destination passed to Clone.
+type remoteMockGitRunner struct {
+	mockGitRunner
+	remote     string
+	clonedURL  string
+	clonedDest string
+}
+
+func (m *remoteMockGitRunner) RemoteURL(repoDir string) (string, error) { return m.remote, nil }
+func (m *remoteMockGitRunner) Clone(url, dest string) error {
+	m.clonedURL = url
+	m.clonedDest = dest
+	return nil
+}
+
+func sampleRepo() model.RepoInfo {
+	return model.RepoInfo{
+		Name:          "repo",
+		CloneURL:      "https://github.com/acme/repo.git",
+		SSHURL:        "git@github.com:acme/repo.git",
+		DefaultBranch: "main",
+	}
+}
+
+func TestProcessRepo_RemoteMismatchSkipsSync(t *testing.T) {
+	eng := &Engine{
+		Git:     &remoteMockGitRunner{mockGitRunner: mockGitRunner{currentBranch: "feature"}, remote: "https://github.com/someone/repo.git"},
+		BaseDir: "/tmp",
+	}
+	result := eng.ProcessRepo(sampleRepo())
+
+	if result.Action != model.ActionRemoteMismatch {
+		t.Fatalf("expected ActionRemoteMismatch, got %v", result.Action)
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "acme/repo") {
+		t.Errorf("expected error naming expected owner/name, got %v", result.Error)
+	}
+	if result.CurrentBranch != "" {
+		t.Error("expected processing to stop before branch inspection")
+	}
+}
+
+func TestProcessRepo_RemoteMatchIgnoresCaseAndProtocol(t *testing.T) {
+	eng := &Engine{
+		Git:     &remoteMockGitRunner{mockGitRunner: mockGitRunner{currentBranch: "main"}, remote: "git@github.com:ACME/Repo.git"},
+		BaseDir: "/tmp",
+	}
+	result := eng.ProcessRepo(sampleRepo())
+	if result.Action == model.ActionRemoteMismatch {
+		t.Fatalf("unexpected remote mismatch: %v", result.Error)
+	}
+}
+
+func TestStatusRepo_RemoteMismatch(t *testing.T) {
+	eng := &Engine{
+		Git:     &remoteMockGitRunner{mockGitRunner: mockGitRunner{currentBranch: "main"}, remote: "/srv/mirror/repo.git"},
+		BaseDir: "/tmp",
+	}
+	if got := eng.StatusRepo(sampleRepo()).Action; got != model.ActionRemoteMismatch {
+		t.Fatalf("expected ActionRemoteMismatch for local path origin, got %v", got)
+	}
+}
+
+// pullCountingGitRunner records whether PullFF was called.
+type pullCountingGitRunner struct {
+	mockGitRunner
+	pulls int
+}
+
+func (m *pullCountingGitRunner) PullFF(repoDir string) (bool, error) {
+	m.pulls++
+	return false, nil
+}
+
+func TestProcessRepo_DivergedSkipsPull(t *testing.T) {
+	git := &pullCountingGitRunner{mockGitRunner: mockGitRunner{currentBranch: "main", ahead: 2, behind: 3}}
+	eng := &Engine{Git: git, BaseDir: "/tmp"}
+
+	result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if result.Action != model.ActionDiverged {
+		t.Fatalf("expected ActionDiverged, got %v", result.Action)
+	}
+	if result.Ahead != 2 || result.Behind != 3 {
+		t.Errorf("expected ahead=2 behind=3, got ahead=%d behind=%d", result.Ahead, result.Behind)
+	}
+	if result.Error != nil {
+		t.Errorf("diverged should not be an error, got %v", result.Error)
+	}
+	if git.pulls != 0 {
+		t.Errorf("expected no pull for a diverged branch, got %d", git.pulls)
+	}
+}
+
+func TestProcessRepo_DriftCorrectedThenDiverged(t *testing.T) {
+	git := &pullCountingGitRunner{mockGitRunner: mockGitRunner{currentBranch: "feature", ahead: 2, behind: 3}}
+	eng := &Engine{Git: git, BaseDir: "/tmp"}
+
+	result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	// Divergence takes precedence as the action; the drift is still recorded
+	// and the checkout is visible in the current branch.
+	if result.Action != model.ActionDiverged || !result.BranchDrift || result.CurrentBranch != "main" {
+		t.Fatalf("expected a corrected drift and ActionDiverged, got %v drift=%t branch=%q", result.Action, result.BranchDrift, result.CurrentBranch)
+	}
+	if git.checkedOut != "main" || git.pulls != 0 {
+		t.Errorf("expected checkout of main and no pull, got checkout %q and %d pulls", git.checkedOut, git.pulls)
+	}
+}
+
+func TestProcessRepo_AheadOnlyOrNoUpstreamStillPulls(t *testing.T) {
+	for _, m := range []mockGitRunner{
+		{currentBranch: "main", ahead: 2},
+		{currentBranch: "main", behind: 4},
+		{currentBranch: "main", aheadBehindErr: errors.New("no upstream configured")},
+	} {
+		git := &pullCountingGitRunner{mockGitRunner: m}
+		eng := &Engine{Git: git, BaseDir: "/tmp"}
+
+		result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+		if result.Action == model.ActionDiverged {
+			t.Errorf("ahead=%d behind=%d err=%v: unexpected ActionDiverged", m.ahead, m.behind, m.aheadBehindErr)
+		}
+		if git.pulls != 1 {
+			t.Errorf("ahead=%d behind=%d err=%v: expected 1 pull, got %d", m.ahead, m.behind, m.aheadBehindErr, git.pulls)
+		}
+	}
+}
+
+func TestProcessRepo_CollectsUnpushedWork(t *testing.T) {
+	branches := []model.BranchState{
+		{Name: "main", Current: true, Upstream: "origin/main"},
+		{Name: "wip", Upstream: "origin/wip", Ahead: 3},
+	}
+	eng := &Engine{
+		Git:     &mockGitRunner{currentBranch: "main", branches: branches, stashes: 1},
+		BaseDir: "/tmp",
+	}
+
+	result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if result.Action != model.ActionAlreadyCurrent {
+		t.Fatalf("expected ActionAlreadyCurrent, got %v", result.Action)
+	}
+	if len(result.Branches) != 2 || result.Stashes != 1 {
+		t.Fatalf("expected branches and stash count to be collected, got %+v / %d", result.Branches, result.Stashes)
+	}
+	if !result.HasUnpushedWork() {
+		t.Error("expected unpushed work")
+	}
+}
+
+func TestStatusRepo_CollectsUnpushedWorkForDirtyRepo(t *testing.T) {
+	eng := &Engine{
+		Git: &mockGitRunner{
+			currentBranch: "main",
+			dirtyFiles:    []model.DirtyFile{{Path: "a.go", Unstaged: true}},
+			branches:      []model.BranchState{{Name: "main", Current: true}},
+			unpublished:   map[string]int{"main": 1},
+		},
+		BaseDir: "/tmp",
+	}
+
+	result := eng.StatusRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if result.Action != model.ActionDirty {
+		t.Fatalf("expected ActionDirty, got %v", result.Action)
+	}
+	if unpushed := result.UnpushedBranches(); len(unpushed) != 1 || unpushed[0].Name != "main" {
+		t.Fatalf("expected main (no upstream) to be unpushed, got %+v", unpushed)
+	}
+}
+
+func TestProcessRepo_CollectsUnpushedWorkWhenFetchFails(t *testing.T) {
+	auth := newGitError("fetch", []byte("fatal: Authentication failed for 'https://github.com/acme/repo.git/'"), errors.New("exit status 128"))
+	git := &flakyGitRunner{
+		mockGitRunner: mockGitRunner{
+			currentBranch: "main",
+			branches:      []model.BranchState{{Name: "wip", Upstream: "origin/wip", Ahead: 2}},
+			stashes:       1,
+		},
+		fetchErrs: []error{auth},
+	}
+	eng := &Engine{Git: git, BaseDir: "/tmp"}
+
+	result := eng.ProcessRepo(sampleRepo())
+
+	if result.Action != model.ActionFetchError {
+		t.Fatalf("expected ActionFetchError, got %v", result.Action)
+	}
+	if unpushed := result.UnpushedBranches(); len(unpushed) != 1 || unpushed[0].Name != "wip" || result.Stashes != 1 {
+		t.Fatalf("expected wip and the stash to be reported, got %+v / %d", unpushed, result.Stashes)
+	}
+}
+
+func TestProcessRepo_CollectsUnpushedWorkOnRemoteMismatch(t *testing.T) {
+	eng := &Engine{
+		Git: &remoteMockGitRunner{
+			mockGitRunner: mockGitRunner{currentBranch: "main", branches: []model.BranchState{{Name: "main", Current: true}}, unpublished: map[string]int{"main": 1}},
+			remote:        "https://github.com/someone/repo.git",
+		},
+		BaseDir: "/tmp",
+	}
+
+	result := eng.ProcessRepo(sampleRepo())
+
+	if result.Action != model.ActionRemoteMismatch {
+		t.Fatalf("expected ActionRemoteMismatch, got %v", result.Action)
+	}
+	if unpushed := result.UnpushedBranches(); len(unpushed) != 1 || unpushed[0].Name != "main" {
+		t.Fatalf("expected main (no upstream) to be unpushed, got %+v", unpushed)
+	}
+}
+
+func TestStatusRepo_PublishedBranchesAreNotUnpushed(t *testing.T) {
+	git := &mockGitRunner{
+		currentBranch: "main",
+		branches: []model.BranchState{
+			{Name: "main", Current: true, Upstream: "origin/main"},
+			{Name: "merged", Upstream: "origin/merged", Gone: true},
+			{Name: "copy"},
+			{Name: "spike"},
+		},
+		unpublished: map[string]int{"spike": 2},
+	}
+	result := (&Engine{Git: git, BaseDir: "/tmp"}).StatusRepo(sampleRepo())
+
+	unpushed := result.UnpushedBranches()
+	if len(unpushed) != 1 || unpushed[0].Name != "spike" || unpushed[0].Unpublished != 2 {
+		t.Fatalf("expected only spike with 2 unpublished commits, got %+v", unpushed)
+	}
+
+	// Branches whose commits cannot be counted stay flagged.
+	git.unpublishedErr = errors.New("rev-list failed")
+	result = (&Engine{Git: git, BaseDir: "/tmp"}).StatusRepo(sampleRepo())
+	if unpushed := result.UnpushedBranches(); len(unpushed) != 3 {
+		t.Fatalf("expected merged, copy, and spike to stay flagged, got %+v", unpushed)
+	}
+}
+
+// branchCountingGitRunner counts BranchStates calls.
+type branchCountingGitRunner struct {
+	mockGitRunner
+	reads int
+}
+
+func (m *branchCountingGitRunner) BranchStates(repoDir string) ([]model.BranchState, error) {
+	m.reads++
+	return m.mockGitRunner.BranchStates(repoDir)
+}
+
+func TestProcessRepo_ReadsBranchesOnce(t *testing.T) {
+	git := &branchCountingGitRunner{mockGitRunner: mockGitRunner{
+		currentBranch: "main",
+		remoteHead:    "master",
+		branches:      []model.BranchState{{Name: "main", Current: true, Upstream: "origin/main"}},
+	}}
+	result := (&Engine{Git: git, BaseDir: "/tmp"}).ProcessRepo(sampleRepo())
+
+	if result.Action != model.ActionAlreadyCurrent || len(result.Branches) != 1 {
+		t.Fatalf("expected an up-to-date repo with its branch, got %v (%+v)", result.Action, result.Branches)
+	}
+	if git.reads != 1 {
+		t.Errorf("expected the branches to be read once, got %d", git.reads)
+	}
+}
+
+// checkoutCountingGitRunner records whether Checkout or PullFF was called.
+type checkoutCountingGitRunner struct {
+	mockGitRunner
+	checkouts, pulls int
+}
+
+func (m *checkoutCountingGitRunner) Checkout(repoDir, branch string) error {
+	m.checkouts++
+	return nil
+}
+
+func (m *checkoutCountingGitRunner) PullFF(repoDir string) (bool, error) {
+	m.pulls++
+	return false, nil
+}
+
+func TestProcessRepo_DetachedHeadIsNotCorrected(t *testing.T) {
+	git := &checkoutCountingGitRunner{mockGitRunner: mockGitRunner{currentBranch: "HEAD"}}
+	eng := &Engine{Git: git, BaseDir: "/tmp"}
+
+	result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if result.Action != model.ActionDetachedHead {
+		t.Fatalf("expected ActionDetachedHead, got %v", result.Action)
+	}
+	if result.BranchDrift {
+		t.Error("detached HEAD should not be reported as branch drift")
+	}
+	if git.checkouts != 0 || git.pulls != 0 {
+		t.Errorf("expected no checkout or pull, got checkouts=%d pulls=%d", git.checkouts, git.pulls)
+	}
+}
+
+func TestProcessRepo_InProgressOperationIsNotCorrected(t *testing.T) {
+	git := &checkoutCountingGitRunner{mockGitRunner: mockGitRunner{currentBranch: "HEAD", operation: "rebase", dirtyFiles: []model.DirtyFile{{Path: "a.go", Kind: model.ChangeUnmerged}}}}
+	eng := &Engine{Git: git, BaseDir: "/tmp"}
+
+	result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if result.Action != model.ActionInProgress {
+		t.Fatalf("expected ActionInProgress, got %v", result.Action)
+	}
+	if result.Operation != "rebase" {
+		t.Errorf("expected operation rebase, got %q", result.Operation)
+	}
+	if git.checkouts != 0 || git.pulls != 0 {
+		t.Errorf("expected no checkout or pull, got checkouts=%d pulls=%d", git.checkouts, git.pulls)
+	}
+}
+
+func TestStatusRepo_ReportsInProgressOperation(t *testing.T) {
+	eng := &Engine{
+		Git:     &mockGitRunner{currentBranch: "feature", operation: "merge", dirtyFiles: []model.DirtyFile{{Path: "a.go", Kind: model.ChangeUnmerged}}},
+		BaseDir: "/tmp",
+	}
+
+	result := eng.StatusRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if result.Action != model.ActionInProgress || result.Operation != "merge" {
+		t.Fatalf("expected in-progress merge, got %v %q", result.Action, result.Operation)
+	}
+	if result.CurrentBranch != "feature" {
+		t.Errorf("expected current branch feature, got %q", result.CurrentBranch)
+	}
+}
+
+func TestCloneRepo_RecordsRepoID(t *testing.T) {
+	git := &mockGitRunner{}
+	eng := &Engine{Git: git, BaseDir: "/tmp"}
+	repo := sampleRepo()
+	repo.ID = 1234
+	eng.CloneRepo(repo)
+	if git.recordedID != 1234 {
+		t.Errorf("recorded ID %d, want 1234", git.recordedID)
+	}
+}
+
+func TestProcessRepo_RecordsMissingRepoID(t *testing.T) {
+	repo := sampleRepo()
+	repo.ID = 1234
+
+	git := &mockGitRunner{currentBranch: "main"}
+	(&Engine{Git: git, BaseDir: "/tmp"}).ProcessRepo(repo)
+	if git.recordedID != 1234 {
+		t.Errorf("expected the ID to be recorded in a clone without one, got %d", git.recordedID)
+	}
+
+	git = &mockGitRunner{currentBranch: "main", repoID: 99}
+	(&Engine{Git: git, BaseDir: "/tmp"}).ProcessRepo(repo)
+	if git.recordedID != 0 {
+		t.Errorf("expected an existing ID to be left alone, got %d recorded", git.recordedID)
+	}
+}
+
+func TestAdoptRename(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "old-name", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git := &remoteMockGitRunner{remote: "git@github.com:acme/old-name.git"}
+	eng := &Engine{Git: git, BaseDir: base}
+	repo := sampleRepo()
+	repo.ID = 1234
+	repo.Directory = "acme"
+
+	if err := eng.AdoptRename("old-name", repo); err != nil {
+		t.Fatalf("AdoptRename returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "acme", "repo", ".git")); err != nil {
+		t.Errorf("expected the clone at its new path: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "old-name")); !os.IsNotExist(err) {
+		t.Errorf("expected the old path to be gone, got %v", err)
+	}
+	if git.remoteSet != repo.SSHURL {
+		t.Errorf("origin set to %q, want the ssh URL the clone already used", git.remoteSet)
+	}
+	if git.recordedID != 1234 {
+		t.Errorf("recorded ID %d, want 1234", git.recordedID)
+	}
+}
+
+func TestAdoptRename_TargetExists(t *testing.T) {
+	base := t.TempDir()
+	for _, dir := range []string{"old-name", "repo"} {
+		if err := os.MkdirAll(filepath.Join(base, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eng := &Engine{Git: &mockGitRunner{}, BaseDir: base}
+	if err := eng.AdoptRename("old-name", sampleRepo()); err == nil {
+		t.Fatal("expected an error when the new path already exists")
+	}
+	if _, err := os.Stat(filepath.Join(base, "old-name")); err != nil {
+		t.Errorf("expected the old clone to be left in place: %v", err)
+	}
+}
+
+func TestLocalWork(t *testing.T) {
+	git := &mockGitRunner{
+		branches: []model.BranchState{
+			{Name: "main", Current: true, Upstream: "origin/main"},
+			{Name: "feature", Upstream: "origin/feature", Ahead: 2},
+		},
+	}
+	eng := &Engine{Git: git, BaseDir: "/tmp"}
+	result := eng.LocalWork("old-tool")
+	if result.Name != "old-tool" || len(result.Branches) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if !result.HasUnpushedWork() {
+		t.Error("expected the branch ahead of its upstream to count as unpushed work")
+	}
+}
+
+// renamedDefaultRunner is a clone whose default branch was renamed from
+// master to main upstream: origin/master is gone and origin/HEAD still names it.
+func renamedDefaultRunner() *mockGitRunner {
+	return &mockGitRunner{
+		currentBranch: "master",
+		remoteHead:    "master",
+		branches:      []model.BranchState{{Name: "master", Current: true, Upstream: "origin/master", Gone: true}},
+	}
+}
+
+func TestProcessRepo_DefaultBranchRenamedReported(t *testing.T) {
+	git := renamedDefaultRunner()
+	eng := &Engine{Git: git, BaseDir: "/tmp"}
+	result := eng.ProcessRepo(sampleRepo())
+
+	if result.Action != model.ActionDefaultBranchRenamed || result.PreviousDefault != "master" {
+		t.Fatalf("expected default-branch-renamed from master, got %v (%q)", result.Action, result.PreviousDefault)
+	}
+	if result.BranchMigrated || git.renamedBranches != "" || git.checkedOut != "" {
+		t.Errorf("expected the clone to be left alone without migration, got rename %q checkout %q", git.renamedBranches, git.checkedOut)
+	}
+}
+
+func TestProcessRepo_DefaultBranchRenamedMigrated(t *testing.T) {
+	git := renamedDefaultRunner()
+	eng := &Engine{Git: git, BaseDir: "/tmp", MigrateDefaultBranch: true}
+	result := eng.ProcessRepo(sampleRepo())
+
+	if result.Action != model.ActionDefaultBranchRenamed || !result.BranchMigrated {
+		t.Fatalf("expected a migrated default-branch-renamed result, got %v (migrated=%t)", result.Action, result.BranchMigrated)
+	}
+	if git.renamedBranches != "master->main" {
+		t.Errorf("renamed %q, want master->main", git.renamedBranches)
+	}
+	if git.checkedOut != "" {
+		t.Errorf("expected no checkout after renaming the current branch, got %q", git.checkedOut)
+	}
+	if result.CurrentBranch != "main" {
+		t.Errorf("current branch %q, want main", result.CurrentBranch)
+	}
+}
+
+func TestProcessRepo_DefaultBranchRenamedDirty(t *testing.T) {
+	git := renamedDefaultRunner()
+	git.dirtyFiles = []model.DirtyFile{{Path: "file.go", Unstaged: true}}
+	eng := &Engine{Git: git, BaseDir: "/tmp", MigrateDefaultBranch: true}
+	result := eng.ProcessRepo(sampleRepo())
+
+	if result.Action != model.ActionDirty || result.PreviousDefault != "master" {
+		t.Fatalf("expected dirty with the rename noted, got %v (%q)", result.Action, result.PreviousDefault)
+	}
+	if git.renamedBranches != "" {
+		t.Errorf("expected a dirty clone to be left untouched, got rename %q", git.renamedBranches)
+	}
+}
+
+func TestProcessRepo_DriftWithoutRenameChecksOut(t *testing.T) {
+	// A gone upstream alone is not a rename when origin/HEAD names the
+	// default branch.
+	git := &mockGitRunner{currentBranch: "feature", remoteHead: "main", branches: []model.BranchState{{Name: "feature", Upstream: "origin/feature", Gone: true}}}
+	result := (&Engine{Git: git, BaseDir: "/tmp", MigrateDefaultBranch: true}).ProcessRepo(sampleRepo())
+	if result.PreviousDefault != "" || git.renamedBranches != "" {
+		t.Errorf("unexpected rename detected for %+v", git.branches)
+	}
+	if result.Action != model.ActionBranchDrift || git.checkedOut != "main" {
+		t.Errorf("expected branch drift with a checkout of main, got %v (%q)", result.Action, git.checkedOut)
+	}
+}
+
+// staleDefaultRunner returns a clone still on master, whose upstream is gone,
+// next to a local main created before the rename was noticed.
+func staleDefaultRunner() *mockGitRunner {
+	return &mockGitRunner{
+		currentBranch: "master",
+		remoteHead:    "master",
+		branches: []model.BranchState{
+			{Name: "master", Current: true, Upstream: "origin/master", Gone: true},
+			{Name: "main", Upstream: "origin/main"},
+		},
+	}
+}
+
+func TestProcessRepo_DefaultBranchRenamedWithExistingBranch(t *testing.T) {
+	tests := []struct {
+		name         string
+		migrate      bool
+		unmerged     bool
+		wantMigrated bool
+	}{
+		{name: "reported", migrate: false},
+		{name: "migrated", migrate: true, wantMigrated: true},
+		{name: "unmerged kept", migrate: true, unmerged: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			git := staleDefaultRunner()
+			git.unmerged = tt.unmerged
+			eng := &Engine{Git: git, BaseDir: "/tmp", MigrateDefaultBranch: tt.migrate}
+			result := eng.ProcessRepo(sampleRepo())
+
+			if result.Action != model.ActionDefaultBranchRenamed || result.PreviousDefault != "master" || !result.DefaultExisted {
+				t.Fatalf("expected default-branch-renamed from master with main existing, got %v (%q, existed=%t)",
+					result.Action, result.PreviousDefault, result.DefaultExisted)
+			}
+			if git.checkedOut != "main" || result.CurrentBranch != "main" {
+				t.Errorf("expected a checkout of the existing main, got %q (current %q)", git.checkedOut, result.CurrentBranch)
+			}
+			if git.renamedBranches != "" {
+				t.Errorf("expected no rename onto the existing main, got %q", git.renamedBranches)
+			}
+			if result.BranchMigrated != tt.wantMigrated {
+				t.Errorf("migrated = %t, want %t", result.BranchMigrated, tt.wantMigrated)
+			}
+			wantRetired := ""
+			if tt.wantMigrated {
+				wantRetired = "master"
+			}
+			if git.retiredBranch != wantRetired {
+				t.Errorf("retired %q, want %q", git.retiredBranch, wantRetired)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+)
+
+// GitError is a failed git command, with its output classified by cause.
+type GitError struct {
+	Op       string // command description, e.g. "fetch" or "pull --ff-only"
+	Output   string // trimmed combined output
+	Category model.ErrorCategory
+	Err      error
+}
+
+func (e *GitError) Error() string {
+	return fmt.Sprintf("git %s: %s: %v", e.Op, e.Output, e.Err)
+}
+
+func (e *GitError) Unwrap() error { return e.Err }
+
+// newGitError wraps a failed command's output and exit error.
+func newGitError(op string, out []byte, err error) *GitError {
+	output := strings.TrimSpace(string(out))
+	return &GitError{Op: op, Output: output, Category: ClassifyGitOutput(output), Err: err}
+}
+
+// ErrorCategoryOf returns the category of a *GitError anywhere in err's chain,
+// or ErrorUncategorized.
+func ErrorCategoryOf(err error) model.ErrorCategory {
+	var gitErr *GitError
+	if errors.As(err, &gitErr) {
+		return gitErr.Category
+	}
+	return model.ErrorUncategorized
+}
+
+// gitErrorPatterns maps lowercase output fragments to categories. Entries are
+// checked in order, so specific HTTP statuses are matched before the generic
+// "unable to access" network message that accompanies them.
+var gitErrorPatterns = []struct {
+	fragment string
+	category model.ErrorCategory
+}{
+	{"no space left on device", model.ErrorDiskFull},
+	{"disk quota exceeded", model.ErrorDiskFull},
+
+	{"another git process seems to be running", model.ErrorLock},
+	{".lock': file exists", model.ErrorLock},
+	{"cannot lock ref", model.ErrorLock},
+	{"unable to lock", model.ErrorLock},
+
+	{"authentication failed", model.ErrorAuth},
+	{"could not read username", model.ErrorAuth},
+	{"could not read password", model.ErrorAuth},
+	{"terminal prompts disabled", model.ErrorAuth},
+	{"invalid username or password", model.ErrorAuth},
+	{"permission denied (publickey", model.ErrorAuth},
+	{"host key verification failed", model.ErrorAuth},
+	{"returned error: 401", model.ErrorAuth},
+	{"returned error: 403", model.ErrorAuth},
+
+	{"repository not found", model.ErrorNotFound},
+	{"does not appear to be a git repository", model.ErrorNotFound},
+	{"returned error: 404", model.ErrorNotFound},
+	{"couldn't find remote ref", model.ErrorNotFound},
+	{"not found in upstream", model.ErrorNotFound},
+
+	{"not possible to fast-forward", model.ErrorNonFastForward},
+	{"diverging branches", model.ErrorNonFastForward},
+	{"non-fast-forward", model.ErrorNonFastForward},
+
+	{"could not resolve host", model.ErrorNetwork},
+	{"temporary failure in name resolution", model.ErrorNetwork},
+	{"connection timed out", model.ErrorNetwork},
+	{"operation timed out", model.ErrorNetwork},
+	{"connection refused", model.ErrorNetwork},
+	{"connection reset", model.ErrorNetwork},
+	{"network is unreachable", model.ErrorNetwork},
+	{"the remote end hung up unexpectedly", model.ErrorNetwork},
+	{"early eof", model.ErrorNetwork},
+	{"rpc failed", model.ErrorNetwork},
+	{"returned error: 5", model.ErrorNetwork}, // 5xx server errors
+	{"unable to access", model.ErrorNetwork},
+	{"could not read from remote repository", model.ErrorNetwork},
+}
+
+// ClassifyGitOutput returns the category of a git failure from its output.
+// This is a pure function for testability.
+func ClassifyGitOutput(output string) model.ErrorCategory {
+	lower := strings.ToLower(output)
+	for _, p := range gitErrorPatterns {
+		if strings.Contains(lower, p.fragment) {
+			return p.category
+		}
+	}
+	return model.ErrorUncategorized
+}
+
+// isTransient reports whether a failure in category may succeed if retried.
+func isTransient(c model.ErrorCategory) bool {
+	return c == model.ErrorNetwork || c == model.ErrorLock
+}
+
+// Retry policy for transient git failures: up to gitRetryAttempts attempts in
+// total, waiting gitRetryDelay before the first retry and doubling after that.
+const (
+	gitRetryAttempts = 3
+	gitRetryDelay    = 2 * time.Second
+)
+
+// retry runs op, retrying it while it fails with a transient error.
+func (e *Engine) retry(op func() error) error {
+	sleep := e.sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	delay := gitRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt == gitRetryAttempts || !isTransient(ErrorCategoryOf(err)) {
+			return err
+		}
+		sleep(delay)
+		delay *= 2
+	}
+}
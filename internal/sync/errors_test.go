@@ -0,0 +1,139 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+)
+
+func TestClassifyGitOutput(t *testing.T) {
+	tests := []struct {
+		output string
+		want   model.ErrorCategory
+	}{
+		{"fatal: unable to access 'https://github.com/acme/repo.git/': Could not resolve host: github.com", model.ErrorNetwork},
+		{"error: RPC failed; curl 56 GnuTLS recv error (-54)\nfatal: early EOF", model.ErrorNetwork},
+		{"fatal: unable to access 'https://github.com/acme/repo.git/': The requested URL returned error: 502", model.ErrorNetwork},
+		{"ssh: connect to host github.com port 22: Connection timed out\nfatal: Could not read from remote repository.", model.ErrorNetwork},
+		{"fatal: unable to access 'https://github.com/acme/repo.git/': The requested URL returned error: 403", model.ErrorAuth},
+		{"remote: Invalid username or password.\nfatal: Authentication failed for 'https://github.com/acme/repo.git/'", model.ErrorAuth},
+		{"git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.", model.ErrorAuth},
+		{"fatal: could not read Username for 'https://github.com': terminal prompts disabled", model.ErrorAuth},
+		{"remote: Repository not found.\nfatal: repository 'https://github.com/acme/gone.git/' not found", model.ErrorNotFound},
+		{"fatal: couldn't find remote ref main", model.ErrorNotFound},
+		{"hint: Diverging branches can't be fast-forwarded\nfatal: Not possible to fast-forward, aborting.", model.ErrorNonFastForward},
+		{"fatal: Unable to create '/repos/demo/.git/index.lock': File exists.\n\nAnother git process seems to be running in this repository", model.ErrorLock},
+		{"error: cannot lock ref 'refs/remotes/origin/main': is at abc but expected def", model.ErrorLock},
+		{"fatal: write error: No space left on device", model.ErrorDiskFull},
+		{"error: pathspec 'nope' did not match any file(s) known to git", model.ErrorUncategorized},
+		{"", model.ErrorUncategorized},
+	}
+	for _, tt := range tests {
+		if got := ClassifyGitOutput(tt.output); got != tt.want {
+			t.Errorf("ClassifyGitOutput(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestGitError_MessageAndCategory(t *testing.T) {
+	exitErr := errors.New("exit status 128")
+	err := newGitError("fetch", []byte("fatal: early EOF\n"), exitErr)
+
+	if got, want := err.Error(), "git fetch: fatal: early EOF: exit status 128"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, exitErr) {
+		t.Error("expected GitError to unwrap to the exit error")
+	}
+	wrapped := fmt.Errorf("syncing: %w", err)
+	if got := ErrorCategoryOf(wrapped); got != model.ErrorNetwork {
+		t.Errorf("ErrorCategoryOf() = %v, want network", got)
+	}
+	if got := ErrorCategoryOf(errors.New("plain")); got != model.ErrorUncategorized {
+		t.Errorf("ErrorCategoryOf(plain) = %v, want other", got)
+	}
+}
+
+// flakyGitRunner fails Fetch with the queued errors before succeeding.
+type flakyGitRunner struct {
+	mockGitRunner
+	fetchErrs []error
+	fetches   int
+}
+
+func (m *flakyGitRunner) Fetch(repoDir string) error {
+	m.fetches++
+	if len(m.fetchErrs) > 0 {
+		err := m.fetchErrs[0]
+		m.fetchErrs = m.fetchErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func TestProcessRepo_RetriesTransientFetchFailure(t *testing.T) {
+	network := newGitError("fetch", []byte("fatal: the remote end hung up unexpectedly"), errors.New("exit status 128"))
+	git := &flakyGitRunner{mockGitRunner: mockGitRunner{currentBranch: "main"}, fetchErrs: []error{network, network}}
+	var slept []time.Duration
+	eng := &Engine{Git: git, BaseDir: "/tmp", sleep: func(d time.Duration) { slept = append(slept, d) }}
+
+	result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if result.Action != model.ActionAlreadyCurrent {
+		t.Fatalf("expected ActionAlreadyCurrent after retries, got %v (%v)", result.Action, result.Error)
+	}
+	if git.fetches != 3 {
+		t.Errorf("expected 3 fetch attempts, got %d", git.fetches)
+	}
+	if len(slept) != 2 || slept[0] != gitRetryDelay || slept[1] != 2*gitRetryDelay {
+		t.Errorf("unexpected backoff: %v", slept)
+	}
+}
+
+func TestProcessRepo_GivesUpAfterRetryLimit(t *testing.T) {
+	network := newGitError("fetch", []byte("fatal: Could not resolve host: github.com"), errors.New("exit status 128"))
+	git := &flakyGitRunner{mockGitRunner: mockGitRunner{currentBranch: "main"}, fetchErrs: []error{network, network, network, network}}
+	eng := &Engine{Git: git, BaseDir: "/tmp", sleep: func(time.Duration) {}}
+
+	result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if result.Action != model.ActionFetchError || result.ErrorCategory != model.ErrorNetwork {
+		t.Fatalf("expected network fetch-error, got %v/%v", result.Action, result.ErrorCategory)
+	}
+	if git.fetches != gitRetryAttempts {
+		t.Errorf("expected %d fetch attempts, got %d", gitRetryAttempts, git.fetches)
+	}
+}
+
+func TestProcessRepo_DoesNotRetryPermanentFailure(t *testing.T) {
+	auth := newGitError("fetch", []byte("fatal: Authentication failed for 'https://github.com/acme/repo.git/'"), errors.New("exit status 128"))
+	git := &flakyGitRunner{mockGitRunner: mockGitRunner{currentBranch: "main"}, fetchErrs: []error{auth}}
+	eng := &Engine{Git: git, BaseDir: "/tmp", sleep: func(time.Duration) { t.Fatal("unexpected retry") }}
+
+	result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if result.Action != model.ActionFetchError || result.ErrorCategory != model.ErrorAuth {
+		t.Fatalf("expected auth fetch-error, got %v/%v", result.Action, result.ErrorCategory)
+	}
+	if git.fetches != 1 {
+		t.Errorf("expected 1 fetch attempt, got %d", git.fetches)
+	}
+}
+
+func TestProcessRepo_OfflineNeverFetches(t *testing.T) {
+	network := newGitError("fetch", []byte("fatal: Could not resolve host: github.com"), errors.New("exit status 128"))
+	git := &flakyGitRunner{mockGitRunner: mockGitRunner{currentBranch: "feature"}, fetchErrs: []error{network}}
+	eng := &Engine{Git: git, BaseDir: "/tmp", Offline: true, sleep: func(time.Duration) { t.Fatal("unexpected retry") }}
+
+	result := eng.ProcessRepo(model.RepoInfo{Name: "repo", DefaultBranch: "main"})
+
+	if git.fetches != 0 {
+		t.Errorf("expected no fetch offline, got %d", git.fetches)
+	}
+	if result.Action != model.ActionBranchDrift || git.checkedOut != "" {
+		t.Errorf("expected branch drift reported without a checkout, got %v (%q)", result.Action, git.checkedOut)
+	}
+}
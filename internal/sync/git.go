@@ -1,8 +1,11 @@
 package sync
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -14,12 +17,22 @@ type GitRunner interface {
 	Clone(url, dest string) error
 	Fetch(repoDir string) error
 	SubmoduleUpdate(repoDir string) error
-	CurrentBranch(repoDir string) (string, error)
-	IsDirty(repoDir string) (bool, []model.DirtyFile, error)
-	DiffStats(repoDir string) (int, int, error) // additions, deletions
+	Status(repoDir string) (model.RepoStatus, error) // branch, upstream tracking, and changed files
+	DiffStats(repoDir string) (int, int, error)      // additions, deletions
 	Checkout(repoDir, branch string) error
-	PullFF(repoDir string) (bool, error) // returns true if changes were pulled
+	RemoteHead(repoDir string) (string, error) // branch origin/HEAD points at; "" when unset
+	RenameDefaultBranch(repoDir, from, to string) error
+	RetireDefaultBranch(repoDir, from, to string) (bool, error)
+	PullFF(repoDir string) (bool, error)          // returns true if changes were pulled
+	AheadBehind(repoDir string) (int, int, error) // commits only on HEAD, only on its upstream
+	BranchStates(repoDir string) ([]model.BranchState, error)
+	UnpublishedCommits(repoDir, branch string) (int, error)
+	StashCount(repoDir string) (int, error)
+	InProgressOperation(repoDir string) (string, error) // "" when no operation is in progress
 	RemoteURL(repoDir string) (string, error)
+	SetRemoteURL(repoDir, url string) error
+	RepoID(repoDir string) (int64, error) // 0 when no ID is recorded
+	SetRepoID(repoDir string, id int64) error
 	StatusShort(repoDir string) (string, error) // returns colorized short status output
 	IgnoredPaths(repoDir string) ([]string, error)
 }
@@ -27,7 +40,8 @@ type GitRunner interface {
 // ExecGitRunner runs real git commands.
 // When tracef is set, the raw output of each command is forwarded to it.
 type ExecGitRunner struct {
-	tracef func(string, ...any)
+	tracef      func(string, ...any)
+	credentials *GitCredentials // set by Engine.SetCredentials; nil leaves git's own setup
 }
 
 func (g *ExecGitRunner) tracefSafe(format string, args ...any) {
@@ -36,93 +50,97 @@ func (g *ExecGitRunner) tracefSafe(format string, args ...any) {
 	}
 }
 
-func (g *ExecGitRunner) Clone(url, dest string) error {
-	cmd := exec.Command("git", "clone", "--recurse-submodules", url, dest)
+// runRemote runs a git command that may contact the remote. Terminal prompts
+// are disabled so a missing credential fails instead of blocking, and the
+// configured credentials are supplied. The token is redacted from the output
+// before it is traced or wrapped in a *GitError.
+func (g *ExecGitRunner) runRemote(op string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var token string
+	if g.credentials != nil {
+		env, t, err := g.credentials.env()
+		if err != nil {
+			return err
+		}
+		cmd.Env = append(cmd.Env, env...)
+		token = t
+	}
 	out, err := cmd.CombinedOutput()
+	out = redactToken(out, token)
 	if s := strings.TrimSpace(string(out)); s != "" {
 		g.tracefSafe("git output:\n%s", s)
 	}
 	if err != nil {
-		return fmt.Errorf("git clone: %s: %w", strings.TrimSpace(string(out)), err)
+		return newGitError(op, out, err)
 	}
 	return nil
 }
 
-func (g *ExecGitRunner) Fetch(repoDir string) error {
-	cmd := exec.Command("git", "-C", repoDir, "fetch", "--all", "--prune")
-	out, err := cmd.CombinedOutput()
-	if s := strings.TrimSpace(string(out)); s != "" {
-		g.tracefSafe("git output:\n%s", s)
-	}
-	if err != nil {
-		return fmt.Errorf("git fetch: %s: %w", strings.TrimSpace(string(out)), err)
-	}
-	return nil
+func (g *ExecGitRunner) Clone(url, dest string) error {
+	return g.runRemote("clone", "clone", "--recurse-submodules", url, dest)
 }
 
-func (g *ExecGitRunner) SubmoduleUpdate(repoDir string) error {
-	cmd := exec.Command("git", "-C", repoDir, "submodule", "update", "--init", "--recursive")
-	out, err := cmd.CombinedOutput()
-	if s := strings.TrimSpace(string(out)); s != "" {
-		g.tracefSafe("git output:\n%s", s)
-	}
-	if err != nil {
-		return fmt.Errorf("git submodule update: %s: %w", strings.TrimSpace(string(out)), err)
-	}
-	return nil
+func (g *ExecGitRunner) Fetch(repoDir string) error {
+	return g.runRemote("fetch", "-C", repoDir, "fetch", "--all", "--prune")
 }
 
-func (g *ExecGitRunner) CurrentBranch(repoDir string) (string, error) {
-	cmd := exec.Command("git", "-C", repoDir, "rev-parse", "--abbrev-ref", "HEAD")
-	out, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("git current branch: %w", err)
-	}
-	branch := strings.TrimSpace(string(out))
-	g.tracefSafe("git output: %s", branch)
-	return branch, nil
+func (g *ExecGitRunner) SubmoduleUpdate(repoDir string) error {
+	return g.runRemote("submodule update", "-C", repoDir, "submodule", "update", "--init", "--recursive")
 }
 
-func (g *ExecGitRunner) IsDirty(repoDir string) (bool, []model.DirtyFile, error) {
-	// Use git status --porcelain to detect dirty state
-	cmd := exec.Command("git", "-C", repoDir, "status", "--porcelain")
+// Status reads the current branch, its upstream tracking state, and every
+// changed file with a single git status call.
+func (g *ExecGitRunner) Status(repoDir string) (model.RepoStatus, error) {
+	cmd := exec.Command("git", "-C", repoDir, "status", "--porcelain=v2", "--branch", "-z")
 	out, err := cmd.Output()
 	if err != nil {
-		return false, nil, fmt.Errorf("git status: %w", err)
+		return model.RepoStatus{}, fmt.Errorf("git status: %w", err)
 	}
 	if s := strings.TrimSpace(string(out)); s != "" {
-		g.tracefSafe("git output:\n%s", s)
+		g.tracefSafe("git output:\n%s", strings.ReplaceAll(s, "\x00", "\n"))
 	}
-	files := ParseGitStatus(string(out))
-	if len(files) == 0 {
-		return false, nil, nil
-	}
-	return true, files, nil
+	return ParseGitStatus(string(out)), nil
 }
 
+// DiffStats counts the lines added and deleted by the staged and unstaged
+// changes together, relative to HEAD. Failures are non-fatal and count
+// nothing.
 func (g *ExecGitRunner) DiffStats(repoDir string) (int, int, error) {
-	// Staged changes
-	cmd := exec.Command("git", "-C", repoDir, "diff", "--cached", "--numstat")
+	cmd := exec.Command("git", "-C", repoDir, "diff", "HEAD", "--numstat")
 	out, err := cmd.Output()
 	if err != nil {
+		// An unborn HEAD has no commit to diff against, so the staged and
+		// unstaged changes are counted separately.
+		if exec.Command("git", "-C", repoDir, "rev-parse", "--verify", "--quiet", "HEAD").Run() != nil {
+			adds, dels := g.unbornDiffStats(repoDir)
+			return adds, dels, nil
+		}
 		return 0, 0, nil // non-fatal
 	}
 	if s := strings.TrimSpace(string(out)); s != "" {
 		g.tracefSafe("git output:\n%s", s)
 	}
 	adds, dels := parseNumstat(string(out))
+	return adds, dels, nil
+}
 
-	// Unstaged changes
-	cmd2 := exec.Command("git", "-C", repoDir, "diff", "--numstat")
-	out2, err := cmd2.Output()
-	if err != nil {
-		return adds, dels, nil
-	}
-	if s := strings.TrimSpace(string(out2)); s != "" {
-		g.tracefSafe("git output:\n%s", s)
+// unbornDiffStats counts the staged and unstaged changes of a repository
+// without commits.
+func (g *ExecGitRunner) unbornDiffStats(repoDir string) (adds, dels int) {
+	for _, args := range [][]string{{"diff", "--cached", "--numstat"}, {"diff", "--numstat"}} {
+		cmd := exec.Command("git", append([]string{"-C", repoDir}, args...)...)
+		out, err := cmd.Output()
+		if err != nil {
+			return adds, dels
+		}
+		if s := strings.TrimSpace(string(out)); s != "" {
+			g.tracefSafe("git output:\n%s", s)
+		}
+		a, d := parseNumstat(string(out))
+		adds, dels = adds+a, dels+d
 	}
-	a2, d2 := parseNumstat(string(out2))
-	return adds + a2, dels + d2, nil
+	return adds, dels
 }
 
 func parseNumstat(output string) (int, int) {
@@ -157,26 +175,206 @@ func (g *ExecGitRunner) Checkout(repoDir, branch string) error {
 		g.tracefSafe("git output:\n%s", s)
 	}
 	if err != nil {
-		return fmt.Errorf("git checkout %s: %s: %w", branch, strings.TrimSpace(string(out)), err)
+		return newGitError("checkout "+branch, out, err)
 	}
 	return nil
 }
 
+func (g *ExecGitRunner) RemoteHead(repoDir string) (string, error) {
+	cmd := exec.Command("git", "-C", repoDir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", nil // origin/HEAD is not set
+	}
+	if err != nil {
+		return "", fmt.Errorf("git symbolic-ref: %w", err)
+	}
+	ref := strings.TrimSpace(string(out))
+	g.tracefSafe("git output: %s", ref)
+	return strings.TrimPrefix(ref, "origin/"), nil
+}
+
+// RenameDefaultBranch moves the local branch from to to, tracks origin/to,
+// and updates origin/HEAD from the remote, after the default branch was
+// renamed on the remote. The commands run in order and stop at the first
+// failure.
+func (g *ExecGitRunner) RenameDefaultBranch(repoDir, from, to string) error {
+	for _, args := range renameDefaultBranchArgs(from, to) {
+		cmd := exec.Command("git", append([]string{"-C", repoDir}, args...)...)
+		out, err := cmd.CombinedOutput()
+		if s := strings.TrimSpace(string(out)); s != "" {
+			g.tracefSafe("git output:\n%s", s)
+		}
+		if err != nil {
+			return newGitError(strings.Join(args[:2], " "), out, err)
+		}
+	}
+	return g.runRemote("remote set-head", append([]string{"-C", repoDir}, setRemoteHeadArgs...)...)
+}
+
+// RetireDefaultBranch deletes the local branch from, left behind by a rename
+// of the default branch when a local to branch already exists, and updates
+// origin/HEAD from the remote. It returns false, changing nothing, when from
+// has commits that are not on to.
+func (g *ExecGitRunner) RetireDefaultBranch(repoDir, from, to string) (bool, error) {
+	cmd := exec.Command("git", "-C", repoDir, "merge-base", "--is-ancestor", "refs/heads/"+from, "refs/heads/"+to)
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil // from has commits of its own
+	}
+	if err != nil {
+		return false, newGitError("merge-base", out, err)
+	}
+	cmd = exec.Command("git", "-C", repoDir, "branch", "-D", from)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return false, newGitError("branch -D", out, err)
+	}
+	if err := g.runRemote("remote set-head", append([]string{"-C", repoDir}, setRemoteHeadArgs...)...); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// renameDefaultBranchArgs returns the local git arguments RenameDefaultBranch
+// runs before updating origin/HEAD.
+func renameDefaultBranchArgs(from, to string) [][]string {
+	return [][]string{
+		{"branch", "-m", from, to},
+		{"branch", "--set-upstream-to=origin/" + to, to},
+	}
+}
+
+// setRemoteHeadArgs points origin/HEAD at the remote's current default branch.
+var setRemoteHeadArgs = []string{"remote", "set-head", "origin", "-a"}
+
 func (g *ExecGitRunner) PullFF(repoDir string) (bool, error) {
 	// Get current HEAD before pull
 	headBefore := getHead(repoDir)
 
-	cmd := exec.Command("git", "-C", repoDir, "pull", "--ff-only")
-	out, err := cmd.CombinedOutput()
+	if err := g.runRemote("pull --ff-only", "-C", repoDir, "pull", "--ff-only"); err != nil {
+		return false, err
+	}
+
+	headAfter := getHead(repoDir)
+	return headBefore != headAfter, nil
+}
+
+// AheadBehind counts the commits reachable only from HEAD and only from its
+// upstream. It fails when the current branch has no upstream.
+func (g *ExecGitRunner) AheadBehind(repoDir string) (int, int, error) {
+	cmd := exec.Command("git", "-C", repoDir, "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("git rev-list ahead/behind: %w", err)
+	}
+	g.tracefSafe("git output: %s", strings.TrimSpace(string(out)))
+	return parseAheadBehind(string(out))
+}
+
+// parseAheadBehind parses the "<ahead>\t<behind>" output of
+// git rev-list --left-right --count.
+func parseAheadBehind(output string) (int, int, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", strings.TrimSpace(output))
+	}
+	ahead, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", strings.TrimSpace(output))
+	}
+	behind, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", strings.TrimSpace(output))
+	}
+	return ahead, behind, nil
+}
+
+// branchStateFormat is the for-each-ref format parsed by ParseBranchStates:
+// NUL-separated HEAD marker, branch, upstream, and tracking status.
+const branchStateFormat = "%(HEAD)%00%(refname:short)%00%(upstream:short)%00%(upstream:track,nobracket)"
+
+// BranchStates lists every local branch with its upstream tracking state.
+func (g *ExecGitRunner) BranchStates(repoDir string) ([]model.BranchState, error) {
+	cmd := exec.Command("git", "-C", repoDir, "for-each-ref", "--format="+branchStateFormat, "refs/heads")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git for-each-ref: %w", err)
+	}
 	if s := strings.TrimSpace(string(out)); s != "" {
-		g.tracefSafe("git output:\n%s", s)
+		g.tracefSafe("git output:\n%s", strings.ReplaceAll(s, "\x00", " "))
+	}
+	return ParseBranchStates(string(out)), nil
+}
+
+// UnpublishedCommits counts the commits on a local branch that no
+// remote-tracking branch contains.
+func (g *ExecGitRunner) UnpublishedCommits(repoDir, branch string) (int, error) {
+	cmd := exec.Command("git", "-C", repoDir, "rev-list", "--count", "refs/heads/"+branch, "--not", "--remotes")
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("git rev-list: %w", err)
 	}
+	g.tracefSafe("git output: %s", strings.TrimSpace(string(out)))
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
 	if err != nil {
-		return false, fmt.Errorf("git pull --ff-only: %s: %w", strings.TrimSpace(string(out)), err)
+		return 0, fmt.Errorf("git rev-list: unexpected output %q", strings.TrimSpace(string(out)))
 	}
+	return n, nil
+}
 
-	headAfter := getHead(repoDir)
-	return headBefore != headAfter, nil
+// StashCount returns the number of stash entries.
+func (g *ExecGitRunner) StashCount(repoDir string) (int, error) {
+	cmd := exec.Command("git", "-C", repoDir, "stash", "list", "--format=%gd")
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("git stash list: %w", err)
+	}
+	g.tracefSafe("git output: %s", strings.TrimSpace(string(out)))
+	return len(splitLines(string(out))), nil
+}
+
+// InProgressOperation returns the git operation that is part-way through in
+// the repository ("merge", "rebase", "am", "cherry-pick", "revert", or
+// "bisect"), or "" when there is none.
+func (g *ExecGitRunner) InProgressOperation(repoDir string) (string, error) {
+	cmd := exec.Command("git", "-C", repoDir, "rev-parse", "--absolute-git-dir")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse: %w", err)
+	}
+	gitDir := strings.TrimSpace(string(out))
+	g.tracefSafe("git output: %s", gitDir)
+	return operationInGitDir(gitDir), nil
+}
+
+// operationStateFiles maps the state files git leaves in the git directory
+// while an operation is stopped part-way to the operation's name. Order
+// matters: a rebase that stops on a conflicting pick also writes
+// CHERRY_PICK_HEAD, and bisect can run alongside any of the others.
+var operationStateFiles = []struct {
+	path      string
+	operation string
+}{
+	{"rebase-merge", "rebase"},
+	{filepath.Join("rebase-apply", "applying"), "am"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// operationInGitDir inspects the state files in gitDir and returns the name of
+// the in-progress operation, or "" when there is none.
+func operationInGitDir(gitDir string) string {
+	for _, s := range operationStateFiles {
+		if _, err := os.Stat(filepath.Join(gitDir, s.path)); err == nil {
+			return s.operation
+		}
+	}
+	return ""
 }
 
 func getHead(repoDir string) string {
@@ -196,6 +394,47 @@ func (g *ExecGitRunner) RemoteURL(repoDir string) (string, error) {
 	return remote, nil
 }
 
+func (g *ExecGitRunner) SetRemoteURL(repoDir, url string) error {
+	cmd := exec.Command("git", "-C", repoDir, "remote", "set-url", "origin", url)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return newGitError("remote set-url", out, err)
+	}
+	return nil
+}
+
+// repoIDKey is the local git config key holding the GitHub repository ID of
+// a clone, so the clone can be recognised after the repository is renamed.
+const repoIDKey = "ghorgsync.repoid"
+
+func (g *ExecGitRunner) RepoID(repoDir string) (int64, error) {
+	cmd := exec.Command("git", "-C", repoDir, "config", "--local", "--get", repoIDKey)
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return 0, nil // key not set
+	}
+	if err != nil {
+		return 0, fmt.Errorf("git config %s: %w", repoIDKey, err)
+	}
+	value := strings.TrimSpace(string(out))
+	g.tracefSafe("git output: %s", value)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in git config", repoIDKey, value)
+	}
+	return id, nil
+}
+
+func (g *ExecGitRunner) SetRepoID(repoDir string, id int64) error {
+	cmd := exec.Command("git", "-C", repoDir, "config", "--local", repoIDKey, strconv.FormatInt(id, 10))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return newGitError("config", out, err)
+	}
+	return nil
+}
+
 func (g *ExecGitRunner) StatusShort(repoDir string) (string, error) {
 	cmd := exec.Command("git", "-C", repoDir, "-c", "color.status=always", "status", "--short")
 	out, err := cmd.Output()
@@ -0,0 +1,62 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAheadBehind(t *testing.T) {
+	ahead, behind, err := parseAheadBehind("2\t5\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ahead != 2 || behind != 5 {
+		t.Errorf("parseAheadBehind() = %d, %d; want 2, 5", ahead, behind)
+	}
+	for _, bad := range []string{"", "3", "a\tb", "1\t2\t3"} {
+		if _, _, err := parseAheadBehind(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestOperationInGitDir(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"MERGE_HEAD"}, "merge"},
+		{[]string{"rebase-merge/"}, "rebase"},
+		{[]string{"rebase-apply/"}, "rebase"},
+		{[]string{"rebase-apply/applying"}, "am"},
+		{[]string{"CHERRY_PICK_HEAD"}, "cherry-pick"},
+		{[]string{"REVERT_HEAD"}, "revert"},
+		{[]string{"BISECT_LOG"}, "bisect"},
+		// A rebase stopped on a conflicting pick also leaves CHERRY_PICK_HEAD.
+		{[]string{"rebase-merge/", "CHERRY_PICK_HEAD"}, "rebase"},
+	}
+	for _, tt := range tests {
+		gitDir := t.TempDir()
+		for _, f := range tt.files {
+			path := filepath.Join(gitDir, f)
+			if strings.HasSuffix(f, "/") {
+				if err := os.MkdirAll(path, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := operationInGitDir(gitDir); got != tt.want {
+			t.Errorf("operationInGitDir(%v) = %q, want %q", tt.files, got, tt.want)
+		}
+	}
+}
@@ -1,18 +1,34 @@
 package sync
 
 import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
 )
 
+// GitCommandRecord describes one completed git operation. It is delivered to
+// the observer registered with Observe, e.g. to write --events-file entries.
+type GitCommandRecord struct {
+	RepoDir  string     `json:"repo_dir"`
+	Commands [][]string `json:"commands"`            // argv of each git command the operation ran
+	ExitCode *int       `json:"exit_code,omitempty"` // nil when a failure has no exit status
+	Error    string     `json:"error,omitempty"`
+	Result   string     `json:"result,omitempty"` // structured result values, e.g. branch="main"
+}
+
 // LoggingGitRunner decorates a GitRunner with verbose command diagnostics.
 // Before each operation it emits "git cmd: <command>" and afterward
 // "git exit: 0" on success or "git exit: 1 error=<message>" on failure,
-// along with any structured result values.
+// along with any structured result values. When an observer is registered,
+// each completed operation is also delivered as a GitCommandRecord.
 type LoggingGitRunner struct {
-	next GitRunner
-	logf func(string, ...any)
+	next    GitRunner
+	logf    func(string, ...any)
+	observe func(GitCommandRecord)
 }
 
 // NewLoggingGitRunner wraps a GitRunner and emits command diagnostics via logf.
@@ -24,124 +40,245 @@ func NewLoggingGitRunner(next GitRunner, logf func(string, ...any)) GitRunner {
 	return &LoggingGitRunner{next: next, logf: logf}
 }
 
-func (g *LoggingGitRunner) Clone(url, dest string) error {
-	g.logf("git cmd: git clone --recurse-submodules %s %s", url, dest)
-	if err := g.next.Clone(url, dest); err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
-		return err
-	}
-	g.logf("git exit: 0")
-	return nil
+// Observe registers fn to receive a record of every completed git operation.
+func (g *LoggingGitRunner) Observe(fn func(GitCommandRecord)) {
+	g.observe = fn
 }
 
-func (g *LoggingGitRunner) Fetch(repoDir string) error {
-	g.logf("git cmd: git -C %s fetch --all --prune", repoDir)
-	if err := g.next.Fetch(repoDir); err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
-		return err
+// begin logs the commands an operation is about to run and returns them.
+func (g *LoggingGitRunner) begin(repoDir string, commands ...[]string) GitCommandRecord {
+	for _, args := range commands {
+		g.logfSafe("git cmd: %s", strings.Join(args, " "))
 	}
-	g.logf("git exit: 0")
-	return nil
+	return GitCommandRecord{RepoDir: repoDir, Commands: commands}
 }
 
-func (g *LoggingGitRunner) SubmoduleUpdate(repoDir string) error {
-	g.logf("git cmd: git -C %s submodule update --init --recursive", repoDir)
-	if err := g.next.SubmoduleUpdate(repoDir); err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
-		return err
+// end logs the outcome of an operation and delivers its record to the observer.
+// result holds optional structured values appended to the exit line.
+func (g *LoggingGitRunner) end(rec GitCommandRecord, err error, result string) {
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			rec.ExitCode = new(exitErr.ExitCode())
+		}
+		rec.Error = err.Error()
+		g.logfSafe("git exit: 1 error=%q", err.Error())
+	} else {
+		rec.ExitCode = new(0)
+		rec.Result = result
+		if result != "" {
+			g.logfSafe("git exit: 0 %s", result)
+		} else {
+			g.logfSafe("git exit: 0")
+		}
+	}
+	if g.observe != nil {
+		g.observe(rec)
 	}
-	g.logf("git exit: 0")
-	return nil
 }
 
-func (g *LoggingGitRunner) CurrentBranch(repoDir string) (string, error) {
-	g.logf("git cmd: git -C %s rev-parse --abbrev-ref HEAD", repoDir)
-	branch, err := g.next.CurrentBranch(repoDir)
-	if err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
-		return "", err
+func (g *LoggingGitRunner) logfSafe(format string, args ...any) {
+	if g.logf != nil {
+		g.logf(format, args...)
 	}
-	g.logf("git exit: 0 branch=%q", branch)
-	return branch, nil
 }
 
-func (g *LoggingGitRunner) IsDirty(repoDir string) (bool, []model.DirtyFile, error) {
-	g.logf("git cmd: git -C %s status --porcelain", repoDir)
-	dirty, files, err := g.next.IsDirty(repoDir)
+func gitArgs(repoDir string, args ...string) []string {
+	return append([]string{"git", "-C", repoDir}, args...)
+}
+
+func (g *LoggingGitRunner) Clone(url, dest string) error {
+	rec := g.begin(dest, []string{"git", "clone", "--recurse-submodules", url, dest})
+	err := g.next.Clone(url, dest)
+	g.end(rec, err, "")
+	return err
+}
+
+func (g *LoggingGitRunner) Fetch(repoDir string) error {
+	rec := g.begin(repoDir, gitArgs(repoDir, "fetch", "--all", "--prune"))
+	err := g.next.Fetch(repoDir)
+	g.end(rec, err, "")
+	return err
+}
+
+func (g *LoggingGitRunner) SubmoduleUpdate(repoDir string) error {
+	rec := g.begin(repoDir, gitArgs(repoDir, "submodule", "update", "--init", "--recursive"))
+	err := g.next.SubmoduleUpdate(repoDir)
+	g.end(rec, err, "")
+	return err
+}
+
+func (g *LoggingGitRunner) Status(repoDir string) (model.RepoStatus, error) {
+	rec := g.begin(repoDir, gitArgs(repoDir, "status", "--porcelain=v2", "--branch", "-z"))
+	status, err := g.next.Status(repoDir)
+	g.end(rec, err, fmt.Sprintf("branch=%q upstream=%q ahead=%d behind=%d files=%d",
+		status.Branch, status.Upstream, status.Ahead, status.Behind, len(status.Files)))
 	if err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
-		return false, nil, err
+		return model.RepoStatus{}, err
 	}
-	g.logf("git exit: 0 dirty=%t files=%d", dirty, len(files))
-	return dirty, files, nil
+	return status, nil
 }
 
 func (g *LoggingGitRunner) DiffStats(repoDir string) (int, int, error) {
-	g.logf("git cmd: git -C %s diff --cached --numstat", repoDir)
-	g.logf("git cmd: git -C %s diff --numstat", repoDir)
+	rec := g.begin(repoDir, gitArgs(repoDir, "diff", "HEAD", "--numstat"))
 	additions, deletions, err := g.next.DiffStats(repoDir)
+	g.end(rec, err, fmt.Sprintf("additions=%d deletions=%d", additions, deletions))
 	if err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
 		return 0, 0, err
 	}
-	g.logf("git exit: 0 additions=%d deletions=%d", additions, deletions)
 	return additions, deletions, nil
 }
 
 func (g *LoggingGitRunner) Checkout(repoDir, branch string) error {
-	g.logf("git cmd: git -C %s checkout %s", repoDir, branch)
-	if err := g.next.Checkout(repoDir, branch); err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
-		return err
+	rec := g.begin(repoDir, gitArgs(repoDir, "checkout", branch))
+	err := g.next.Checkout(repoDir, branch)
+	g.end(rec, err, "")
+	return err
+}
+
+func (g *LoggingGitRunner) RemoteHead(repoDir string) (string, error) {
+	rec := g.begin(repoDir, gitArgs(repoDir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"))
+	branch, err := g.next.RemoteHead(repoDir)
+	g.end(rec, err, fmt.Sprintf("branch=%q", branch))
+	if err != nil {
+		return "", err
+	}
+	return branch, nil
+}
+
+func (g *LoggingGitRunner) RenameDefaultBranch(repoDir, from, to string) error {
+	var commands [][]string
+	for _, args := range renameDefaultBranchArgs(from, to) {
+		commands = append(commands, gitArgs(repoDir, args...))
 	}
-	g.logf("git exit: 0")
-	return nil
+	commands = append(commands, gitArgs(repoDir, setRemoteHeadArgs...))
+	rec := g.begin(repoDir, commands...)
+	err := g.next.RenameDefaultBranch(repoDir, from, to)
+	g.end(rec, err, "")
+	return err
+}
+
+func (g *LoggingGitRunner) RetireDefaultBranch(repoDir, from, to string) (bool, error) {
+	rec := g.begin(repoDir,
+		gitArgs(repoDir, "merge-base", "--is-ancestor", "refs/heads/"+from, "refs/heads/"+to),
+		gitArgs(repoDir, "branch", "-D", from),
+		gitArgs(repoDir, setRemoteHeadArgs...))
+	deleted, err := g.next.RetireDefaultBranch(repoDir, from, to)
+	g.end(rec, err, fmt.Sprintf("deleted=%t", deleted))
+	return deleted, err
 }
 
 func (g *LoggingGitRunner) PullFF(repoDir string) (bool, error) {
-	g.logf("git cmd: git -C %s pull --ff-only", repoDir)
+	rec := g.begin(repoDir, gitArgs(repoDir, "pull", "--ff-only"))
 	updated, err := g.next.PullFF(repoDir)
+	g.end(rec, err, fmt.Sprintf("updated=%t", updated))
 	if err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
 		return false, err
 	}
-	g.logf("git exit: 0 updated=%t", updated)
 	return updated, nil
 }
 
+func (g *LoggingGitRunner) AheadBehind(repoDir string) (int, int, error) {
+	rec := g.begin(repoDir, gitArgs(repoDir, "rev-list", "--left-right", "--count", "HEAD...@{upstream}"))
+	ahead, behind, err := g.next.AheadBehind(repoDir)
+	g.end(rec, err, fmt.Sprintf("ahead=%d behind=%d", ahead, behind))
+	if err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
+func (g *LoggingGitRunner) BranchStates(repoDir string) ([]model.BranchState, error) {
+	rec := g.begin(repoDir, gitArgs(repoDir, "for-each-ref", "--format="+branchStateFormat, "refs/heads"))
+	branches, err := g.next.BranchStates(repoDir)
+	g.end(rec, err, fmt.Sprintf("branches=%d", len(branches)))
+	if err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
+func (g *LoggingGitRunner) UnpublishedCommits(repoDir, branch string) (int, error) {
+	rec := g.begin(repoDir, gitArgs(repoDir, "rev-list", "--count", "refs/heads/"+branch, "--not", "--remotes"))
+	n, err := g.next.UnpublishedCommits(repoDir, branch)
+	g.end(rec, err, fmt.Sprintf("commits=%d", n))
+	return n, err
+}
+
+func (g *LoggingGitRunner) StashCount(repoDir string) (int, error) {
+	rec := g.begin(repoDir, gitArgs(repoDir, "stash", "list", "--format=%gd"))
+	count, err := g.next.StashCount(repoDir)
+	g.end(rec, err, fmt.Sprintf("stashes=%d", count))
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (g *LoggingGitRunner) InProgressOperation(repoDir string) (string, error) {
+	rec := g.begin(repoDir, gitArgs(repoDir, "rev-parse", "--absolute-git-dir"))
+	operation, err := g.next.InProgressOperation(repoDir)
+	g.end(rec, err, fmt.Sprintf("operation=%q", operation))
+	if err != nil {
+		return "", err
+	}
+	return operation, nil
+}
+
 func (g *LoggingGitRunner) RemoteURL(repoDir string) (string, error) {
-	g.logf("git cmd: git -C %s remote get-url origin", repoDir)
+	rec := g.begin(repoDir, gitArgs(repoDir, "remote", "get-url", "origin"))
 	remote, err := g.next.RemoteURL(repoDir)
+	g.end(rec, err, fmt.Sprintf("remote=%q", remote))
 	if err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
 		return "", err
 	}
-	g.logf("git exit: 0 remote=%q", remote)
 	return remote, nil
 }
 
-func (g *LoggingGitRunner) StatusShort(repoDir string) (string, error) {
-	g.logf("git cmd: git -C %s -c color.status=always status --short", repoDir)
-	status, err := g.next.StatusShort(repoDir)
+func (g *LoggingGitRunner) SetRemoteURL(repoDir, url string) error {
+	rec := g.begin(repoDir, gitArgs(repoDir, "remote", "set-url", "origin", url))
+	err := g.next.SetRemoteURL(repoDir, url)
+	g.end(rec, err, "")
+	return err
+}
+
+func (g *LoggingGitRunner) RepoID(repoDir string) (int64, error) {
+	rec := g.begin(repoDir, gitArgs(repoDir, "config", "--local", "--get", repoIDKey))
+	id, err := g.next.RepoID(repoDir)
+	g.end(rec, err, fmt.Sprintf("repoid=%d", id))
 	if err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
-		return "", err
+		return 0, err
 	}
+	return id, nil
+}
+
+func (g *LoggingGitRunner) SetRepoID(repoDir string, id int64) error {
+	rec := g.begin(repoDir, gitArgs(repoDir, "config", "--local", repoIDKey, strconv.FormatInt(id, 10)))
+	err := g.next.SetRepoID(repoDir, id)
+	g.end(rec, err, "")
+	return err
+}
+
+func (g *LoggingGitRunner) StatusShort(repoDir string) (string, error) {
+	rec := g.begin(repoDir, gitArgs(repoDir, "-c", "color.status=always", "status", "--short"))
+	status, err := g.next.StatusShort(repoDir)
 	lines := 0
 	if trimmed := strings.TrimSpace(status); trimmed != "" {
 		lines = len(strings.Split(trimmed, "\n"))
 	}
-	g.logf("git exit: 0 lines=%d", lines)
+	g.end(rec, err, fmt.Sprintf("lines=%d", lines))
+	if err != nil {
+		return "", err
+	}
 	return status, nil
 }
 
 func (g *LoggingGitRunner) IgnoredPaths(repoDir string) ([]string, error) {
-	g.logf("git cmd: git -C %s ls-files -z --others --ignored --exclude-standard", repoDir)
+	rec := g.begin(repoDir, gitArgs(repoDir, "ls-files", "-z", "--others", "--ignored", "--exclude-standard"))
 	paths, err := g.next.IgnoredPaths(repoDir)
+	g.end(rec, err, fmt.Sprintf("ignored-paths=%d", len(paths)))
 	if err != nil {
-		g.logf("git exit: 1 error=%q", err.Error())
 		return nil, err
 	}
-	g.logf("git exit: 0 ignored-paths=%d", len(paths))
 	return paths, nil
 }
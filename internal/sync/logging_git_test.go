@@ -3,6 +3,7 @@ package sync
 import (
 	"errors"
 	"fmt"
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -10,25 +11,19 @@ import (
 )
 
 type loggingMockGitRunner struct {
-	cloneErr      error
-	fetchErr      error
-	currentBranch string
-	currentErr    error
-	dirty         bool
-	dirtyFiles    []model.DirtyFile
-	dirtyErr      error
-	statusShort   string
-	statusErr     error
+	cloneErr    error
+	fetchErr    error
+	status      model.RepoStatus
+	statusErr   error
+	statusShort string
+	shortErr    error
 }
 
 func (m *loggingMockGitRunner) Clone(url, dest string) error         { return m.cloneErr }
 func (m *loggingMockGitRunner) Fetch(repoDir string) error           { return m.fetchErr }
 func (m *loggingMockGitRunner) SubmoduleUpdate(repoDir string) error { return nil }
-func (m *loggingMockGitRunner) CurrentBranch(repoDir string) (string, error) {
-	return m.currentBranch, m.currentErr
-}
-func (m *loggingMockGitRunner) IsDirty(repoDir string) (bool, []model.DirtyFile, error) {
-	return m.dirty, m.dirtyFiles, m.dirtyErr
+func (m *loggingMockGitRunner) Status(repoDir string) (model.RepoStatus, error) {
+	return m.status, m.statusErr
 }
 func (m *loggingMockGitRunner) DiffStats(repoDir string) (int, int, error) { return 2, 1, nil }
 func (m *loggingMockGitRunner) Checkout(repoDir, branch string) error      { return nil }
@@ -37,9 +32,31 @@ func (m *loggingMockGitRunner) RemoteURL(repoDir string) (string, error) {
 	return "https://github.com/acme/repo.git", nil
 }
 func (m *loggingMockGitRunner) StatusShort(repoDir string) (string, error) {
-	return m.statusShort, m.statusErr
+	return m.statusShort, m.shortErr
 }
 func (m *loggingMockGitRunner) IgnoredPaths(repoDir string) ([]string, error) { return nil, nil }
+func (m *loggingMockGitRunner) AheadBehind(repoDir string) (int, int, error)  { return 1, 2, nil }
+func (m *loggingMockGitRunner) BranchStates(repoDir string) ([]model.BranchState, error) {
+	return []model.BranchState{{Name: "main", Upstream: "origin/main"}}, nil
+}
+func (m *loggingMockGitRunner) UnpublishedCommits(repoDir, branch string) (int, error) {
+	return 0, nil
+}
+func (m *loggingMockGitRunner) StashCount(repoDir string) (int, error) { return 0, nil }
+func (m *loggingMockGitRunner) InProgressOperation(repoDir string) (string, error) {
+	return "", nil
+}
+func (m *loggingMockGitRunner) SetRemoteURL(repoDir, url string) error    { return nil }
+func (m *loggingMockGitRunner) RepoID(repoDir string) (int64, error)      { return 42, nil }
+func (m *loggingMockGitRunner) SetRepoID(repoDir string, id int64) error  { return nil }
+func (m *loggingMockGitRunner) RemoteHead(repoDir string) (string, error) { return "main", nil }
+func (m *loggingMockGitRunner) RenameDefaultBranch(repoDir, from, to string) error {
+	return nil
+}
+
+func (m *loggingMockGitRunner) RetireDefaultBranch(repoDir, from, to string) (bool, error) {
+	return true, nil
+}
 
 func TestNewLoggingGitRunner_WithNilLoggerReturnsOriginalRunner(t *testing.T) {
 	base := &loggingMockGitRunner{}
@@ -99,28 +116,23 @@ func TestLoggingGitRunner_LogsErrors(t *testing.T) {
 func TestLoggingGitRunner_LogsStructuredResults(t *testing.T) {
 	var logs []string
 	runner := NewLoggingGitRunner(&loggingMockGitRunner{
-		currentBranch: "main",
-		dirty:         true,
-		dirtyFiles:    []model.DirtyFile{{Path: "main.go", Unstaged: true}},
-		statusShort:   " M main.go\n?? new.txt\n",
+		status: model.RepoStatus{
+			Branch:   "main",
+			Upstream: "origin/main",
+			Ahead:    1,
+			Files:    []model.DirtyFile{{Path: "main.go", Unstaged: true}},
+		},
+		statusShort: " M main.go\n?? new.txt\n",
 	}, func(format string, args ...any) {
 		logs = append(logs, fmt.Sprintf(format, args...))
 	})
 
-	branch, err := runner.CurrentBranch("/repos/repo")
-	if err != nil {
-		t.Fatalf("current branch failed: %v", err)
-	}
-	if branch != "main" {
-		t.Fatalf("unexpected branch: %s", branch)
-	}
-
-	dirty, files, err := runner.IsDirty("/repos/repo")
+	status, err := runner.Status("/repos/repo")
 	if err != nil {
-		t.Fatalf("is dirty failed: %v", err)
+		t.Fatalf("status failed: %v", err)
 	}
-	if !dirty || len(files) != 1 {
-		t.Fatalf("unexpected dirty result: dirty=%t files=%d", dirty, len(files))
+	if status.Branch != "main" || len(status.Files) != 1 {
+		t.Fatalf("unexpected status: %+v", status)
 	}
 
 	if _, err := runner.StatusShort("/repos/repo"); err != nil {
@@ -128,15 +140,37 @@ func TestLoggingGitRunner_LogsStructuredResults(t *testing.T) {
 	}
 
 	joined := strings.Join(logs, "\n")
-	if !strings.Contains(joined, `branch="main"`) {
-		t.Fatalf("expected branch result in logs, got: %s", joined)
+	if !strings.Contains(joined, "git cmd: git -C /repos/repo status --porcelain=v2 --branch -z") {
+		t.Fatalf("expected status command in logs, got: %s", joined)
 	}
-	if !strings.Contains(joined, "dirty=true files=1") {
-		t.Fatalf("expected dirty summary in logs, got: %s", joined)
+	if !strings.Contains(joined, `branch="main" upstream="origin/main" ahead=1 behind=0 files=1`) {
+		t.Fatalf("expected status summary in logs, got: %s", joined)
 	}
 	if !strings.Contains(joined, "lines=2") {
 		t.Fatalf("expected status line count in logs, got: %s", joined)
 	}
+
+	if id, err := runner.RepoID("/repos/repo"); err != nil || id != 42 {
+		t.Fatalf("RepoID = %d, %v", id, err)
+	}
+	joined = strings.Join(logs, "\n")
+	if !strings.Contains(joined, "git cmd: git -C /repos/repo config --local --get ghorgsync.repoid") || !strings.Contains(joined, "repoid=42") {
+		t.Fatalf("expected repo ID command and result in logs, got: %s", joined)
+	}
+
+	if err := runner.RenameDefaultBranch("/repos/repo", "master", "main"); err != nil {
+		t.Fatalf("RenameDefaultBranch returned error: %v", err)
+	}
+	joined = strings.Join(logs, "\n")
+	for _, want := range []string{
+		"git cmd: git -C /repos/repo branch -m master main",
+		"git cmd: git -C /repos/repo branch --set-upstream-to=origin/main main",
+		"git cmd: git -C /repos/repo remote set-head origin -a",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in logs, got: %s", want, joined)
+		}
+	}
 	// Verify clean exit-code format throughout
 	if !strings.Contains(joined, "git exit: 0") {
 		t.Fatalf("expected 'git exit: 0' in logs, got: %s", joined)
@@ -158,11 +192,8 @@ func TestLoggingGitRunner_DiffStatsLogsExitCode(t *testing.T) {
 	}
 
 	joined := strings.Join(logs, "\n")
-	if !strings.Contains(joined, "git cmd: git -C /repos/repo diff --cached --numstat") {
-		t.Fatalf("expected staged diff command in logs, got: %s", joined)
-	}
-	if !strings.Contains(joined, "git cmd: git -C /repos/repo diff --numstat") {
-		t.Fatalf("expected unstaged diff command in logs, got: %s", joined)
+	if !strings.Contains(joined, "git cmd: git -C /repos/repo diff HEAD --numstat") {
+		t.Fatalf("expected diff command in logs, got: %s", joined)
 	}
 	if !strings.Contains(joined, "git exit: 0 additions=2 deletions=1") {
 		t.Fatalf("expected diff stats exit in logs, got: %s", joined)
@@ -189,3 +220,63 @@ func TestNewEngine_VerbosityOneWrapsWithLogging(t *testing.T) {
 		t.Fatal("expected LoggingGitRunner at verbosity 1")
 	}
 }
+
+func TestLoggingGitRunner_ObserverReceivesRecords(t *testing.T) {
+	var records []GitCommandRecord
+	runner := &LoggingGitRunner{next: &loggingMockGitRunner{fetchErr: errors.New("network down")}}
+	runner.Observe(func(rec GitCommandRecord) { records = append(records, rec) })
+
+	if err := runner.Fetch("/repos/demo"); err == nil {
+		t.Fatal("expected fetch error")
+	}
+	if _, _, err := runner.DiffStats("/repos/demo"); err != nil {
+		t.Fatalf("diff stats failed: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	fetch := records[0]
+	// A failure without an exit status carries no exit code.
+	if fetch.RepoDir != "/repos/demo" || fetch.ExitCode != nil || fetch.Error != "network down" {
+		t.Fatalf("unexpected fetch record: %+v", fetch)
+	}
+	if got := strings.Join(fetch.Commands[0], " "); got != "git -C /repos/demo fetch --all --prune" {
+		t.Fatalf("unexpected fetch command: %q", got)
+	}
+	diff := records[1]
+	if diff.ExitCode == nil || *diff.ExitCode != 0 || len(diff.Commands) != 1 || diff.Result != "additions=2 deletions=1" {
+		t.Fatalf("unexpected diff record: %+v", diff)
+	}
+}
+
+func TestLoggingGitRunner_ObserverReceivesGitExitCode(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 128").Run()
+	if exitErr == nil {
+		t.Fatal("expected the command to fail")
+	}
+	fetchErr := newGitError("fetch", []byte("fatal: repository not found"), exitErr)
+	var rec GitCommandRecord
+	runner := &LoggingGitRunner{next: &loggingMockGitRunner{fetchErr: fetchErr}}
+	runner.Observe(func(r GitCommandRecord) { rec = r })
+
+	_ = runner.Fetch("/repos/demo")
+
+	if rec.ExitCode == nil || *rec.ExitCode != 128 {
+		t.Fatalf("expected git's exit status 128, got %v", rec.ExitCode)
+	}
+}
+
+func TestEngine_ObserveGitAtVerbosityZero(t *testing.T) {
+	eng := NewEngine("/tmp", 0, nil, nil)
+	eng.Git = &loggingMockGitRunner{}
+	var records []GitCommandRecord
+	eng.ObserveGit(func(rec GitCommandRecord) { records = append(records, rec) })
+
+	if err := eng.Git.Fetch("/repos/demo"); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+}
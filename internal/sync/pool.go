@@ -0,0 +1,45 @@
+package sync
+
+import (
+	gosync "sync"
+)
+
+// RunOrdered calls work for every index in [0, n) using up to jobs concurrent
+// workers. Each result is passed to emit on the calling goroutine in index
+// order, so reporting stays deterministic no matter which repository finishes
+// first. A jobs value below 1 is treated as 1 (serial processing).
+func RunOrdered[T any](n, jobs int, work func(int) T, emit func(int, T)) {
+	if n <= 0 {
+		return
+	}
+	jobs = min(max(jobs, 1), n)
+
+	results := make([]T, n)
+	done := make([]chan struct{}, n)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	indexes := make(chan int)
+	var wg gosync.WaitGroup
+	for range jobs {
+		wg.Go(func() {
+			for i := range indexes {
+				results[i] = work(i)
+				close(done[i])
+			}
+		})
+	}
+	go func() {
+		for i := range n {
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	for i := range n {
+		<-done[i]
+		emit(i, results[i])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunOrdered_EmitsInIndexOrder(t *testing.T) {
+	var emitted []int
+	RunOrdered(5, 3, func(i int) int {
+		// Later indexes finish first to prove emit order does not follow completion order.
+		time.Sleep(time.Duration(5-i) * 5 * time.Millisecond)
+		return i * 10
+	}, func(i int, result int) {
+		if result != i*10 {
+			t.Errorf("result for index %d = %d, want %d", i, result, i*10)
+		}
+		emitted = append(emitted, i)
+	})
+
+	if len(emitted) != 5 {
+		t.Fatalf("expected 5 emitted results, got %d", len(emitted))
+	}
+	for i, got := range emitted {
+		if got != i {
+			t.Fatalf("emit order = %v, want ascending indexes", emitted)
+		}
+	}
+}
+
+func TestRunOrdered_BoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	RunOrdered(20, 4, func(i int) struct{} {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		running.Add(-1)
+		return struct{}{}
+	}, func(int, struct{}) {})
+
+	if got := peak.Load(); got > 4 {
+		t.Fatalf("peak concurrency = %d, want at most 4", got)
+	}
+}
+
+func TestRunOrdered_ZeroJobsRunsSerially(t *testing.T) {
+	var running atomic.Int32
+	count := 0
+	RunOrdered(3, 0, func(i int) int {
+		if running.Add(1) > 1 {
+			t.Error("expected serial execution when jobs is 0")
+		}
+		defer running.Add(-1)
+		return i
+	}, func(int, int) { count++ })
+
+	if count != 3 {
+		t.Fatalf("expected 3 results, got %d", count)
+	}
+}
@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"os/exec"
+	"strings"
+	gosync "sync"
+)
+
+// Remote describes the parts of a git remote URL that ghorgsync compares.
+type Remote struct {
+	Protocol string // "https" (also used for http), "ssh", "git", or "" when unrecognised
+	Host     string
+	Owner    string
+	Name     string // repository name without a trailing .git
+}
+
+// ParseRemote parses the remote URL forms git accepts for GitHub repositories:
+// https://host/owner/name.git, ssh://git@host/owner/name.git and the
+// scp-like git@host:owner/name.git. Unrecognised input yields a zero Remote.
+// This is a pure function for testability.
+func ParseRemote(raw string) Remote {
+	raw = strings.TrimSpace(raw)
+	var r Remote
+	var path string
+
+	if scheme, rest, ok := strings.Cut(raw, "://"); ok {
+		switch strings.ToLower(scheme) {
+		case "https", "http":
+			r.Protocol = "https"
+		case "ssh", "git+ssh":
+			r.Protocol = "ssh"
+		case "git":
+			r.Protocol = "git"
+		default:
+			return Remote{}
+		}
+		hostPart, p, _ := strings.Cut(rest, "/")
+		if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+			hostPart = hostPart[at+1:]
+		}
+		r.Host = hostPart
+		path = p
+	} else if hostPart, p, ok := strings.Cut(raw, ":"); ok && !strings.Contains(hostPart, "/") {
+		// scp-like syntax: [user@]host:owner/name.git
+		if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+			hostPart = hostPart[at+1:]
+		}
+		r.Protocol = "ssh"
+		r.Host = hostPart
+		path = p
+	} else {
+		return Remote{}
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) >= 2 {
+		r.Owner = segments[len(segments)-2]
+		r.Name = strings.TrimSuffix(segments[len(segments)-1], ".git")
+	}
+	return r
+}
+
+// SameRepo reports whether r and other name the same owner/name on the same
+// host. The protocol is ignored so https and ssh forms match, and names and
+// hosts compare case-insensitively as GitHub's do (see hostName for how SSH
+// hosts are resolved).
+func (r Remote) SameRepo(other Remote) bool {
+	return strings.EqualFold(r.Owner, other.Owner) && strings.EqualFold(r.Name, other.Name) &&
+		r.hostName() == other.hostName()
+}
+
+// hostName returns the host r connects to, in lower case and without a port.
+// An SSH host is resolved through the SSH configuration, so an alias such as
+// github-work yields the github.com it stands for, and ssh.github.com,
+// GitHub's SSH over the HTTPS port, counts as github.com.
+func (r Remote) hostName() string {
+	host, _, _ := strings.Cut(r.Host, ":")
+	if r.Protocol == "ssh" {
+		host = sshHostName(host)
+	}
+	host = strings.ToLower(host)
+	if host == "ssh.github.com" {
+		return "github.com"
+	}
+	return host
+}
+
+// sshHostName resolves an SSH host alias to the host name ssh connects to.
+// Tests replace it so they do not depend on the SSH configuration.
+var sshHostName = resolveSSHHostName
+
+// sshHostNames caches resolveSSHHostName, which runs ssh, per alias.
+var sshHostNames = struct {
+	gosync.Mutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+// resolveSSHHostName returns the HostName that ssh -G reports for alias, or
+// alias itself when ssh cannot resolve it.
+func resolveSSHHostName(alias string) string {
+	sshHostNames.Lock()
+	defer sshHostNames.Unlock()
+	if host, ok := sshHostNames.m[alias]; ok {
+		return host
+	}
+	host := alias
+	// A leading dash would be read as an option.
+	if alias != "" && !strings.HasPrefix(alias, "-") {
+		if out, err := exec.Command("ssh", "-G", alias).Output(); err == nil {
+			for line := range strings.SplitSeq(string(out), "\n") {
+				if name, ok := strings.CutPrefix(line, "hostname "); ok {
+					host = strings.TrimSpace(name)
+					break
+				}
+			}
+		}
+	}
+	sshHostNames.m[alias] = host
+	return host
+}
@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"testing"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Remote
+	}{
+		{"https://github.com/acme/repo.git", Remote{Protocol: "https", Host: "github.com", Owner: "acme", Name: "repo"}},
+		{"https://github.com/acme/repo", Remote{Protocol: "https", Host: "github.com", Owner: "acme", Name: "repo"}},
+		{"http://ghes.example.com/acme/repo.git", Remote{Protocol: "https", Host: "ghes.example.com", Owner: "acme", Name: "repo"}},
+		{"https://user@github.com/acme/repo.git/", Remote{Protocol: "https", Host: "github.com", Owner: "acme", Name: "repo"}},
+		{"git@github.com:acme/repo.git", Remote{Protocol: "ssh", Host: "github.com", Owner: "acme", Name: "repo"}},
+		{"ssh://git@github.com/acme/repo.git", Remote{Protocol: "ssh", Host: "github.com", Owner: "acme", Name: "repo"}},
+		{"ssh://git@ghes.example.com:2222/acme/repo.git", Remote{Protocol: "ssh", Host: "ghes.example.com:2222", Owner: "acme", Name: "repo"}},
+		{"/local/path/repo.git", Remote{}},
+		{"", Remote{}},
+	}
+	for _, tt := range tests {
+		if got := ParseRemote(tt.input); got != tt.want {
+			t.Errorf("ParseRemote(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRemote_SameRepo(t *testing.T) {
+	aliases := map[string]string{"github-work": "github.com", "ghes": "ghes.example.com", "github.com": "ssh.github.com"}
+	defer func(orig func(string) string) { sshHostName = orig }(sshHostName)
+	sshHostName = func(alias string) string {
+		if host, ok := aliases[alias]; ok {
+			return host
+		}
+		return alias
+	}
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://github.com/acme/repo.git", "git@github.com:Acme/Repo.git", true},
+		{"https://GitHub.com/acme/repo.git", "https://github.com/acme/repo", true},
+		{"https://github.com/acme/repo.git", "git@github-work:acme/repo.git", true},
+		{"https://ghes.example.com/acme/repo.git", "ssh://git@ghes:2222/acme/repo.git", true},
+		{"https://github.com/acme/repo.git", "https://ghes.example.com/acme/repo.git", false},
+		{"https://github.com/acme/repo.git", "git@ghes:acme/repo.git", false},
+		{"https://github.com/acme/repo.git", "https://github.com/someone/repo.git", false},
+	}
+	for _, tt := range tests {
+		if got := ParseRemote(tt.a).SameRepo(ParseRemote(tt.b)); got != tt.want {
+			t.Errorf("SameRepo(%q, %q) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -12,9 +13,11 @@ import (
 
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/cleanup"
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/config"
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/events"
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/github"
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/output"
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/report"
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/scanner"
 	"github.com/UnitVectorY-Labs/ghorgsync/internal/sync"
 )
@@ -51,6 +54,14 @@ func (c *countFlag) Set(s string) error {
 
 // main is the entry point for the ghorgsync command-line tool.
 func main() {
+	// git re-invokes ghorgsync as a credential helper (see sync.GitCredentials)
+	if len(os.Args) > 1 && os.Args[1] == sync.CredentialHelperArg {
+		if err := sync.ServeCredentialHelper(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Set the build version from the build info if not set by the build system
 	if Version == "dev" || Version == "" {
 		if bi, ok := debug.ReadBuildInfo(); ok {
@@ -71,6 +82,12 @@ func main() {
 	cleanFlag := flag.Bool("clean", false, "Remove git-ignored files and directories after syncing (asks for confirmation)")
 	forceFlag := flag.Bool("force", false, "Skip the confirmation required by --clean")
 	dryRunFlag := flag.Bool("dry-run", false, "With --clean, report ignored content that would be removed without deleting it")
+	migrateDefaultBranchFlag := flag.Bool("migrate-default-branch", false, "Rename local branches left behind by a default branch rename upstream, or delete them once merged when the new branch exists, and update origin/HEAD")
+	adoptRenamesFlag := flag.Bool("adopt-renames", false, "Move clones of renamed repositories to their new path and update origin instead of reporting them")
+	outputFlag := flag.String("output", "text", "Output format: text or json (json writes a single report document to stdout and human-readable output to stderr)")
+	jobsFlag := flag.Int("jobs", 0, "Number of repositories to process in parallel (overrides the jobs config key; default 1)")
+	offlineFlag := flag.Bool("offline", false, "Use the cached repository inventory instead of the GitHub API and report local state only, like --status (requires an earlier online run)")
+	eventsFlag := flag.String("events-file", "", "Write newline-delimited JSON events as they happen to this file path or inherited descriptor (fd:N)")
 	flag.Parse()
 
 	// Mode flags are mutually exclusive
@@ -82,18 +99,47 @@ func main() {
 		fmt.Fprintln(os.Stderr, "error: --force and --dry-run require --clean")
 		os.Exit(1)
 	}
-	if *cleanFlag && (*cloneOnlyFlag || *statusFlag) {
+	if *offlineFlag && *cloneOnlyFlag {
+		fmt.Fprintln(os.Stderr, "error: --offline cannot be used with --clone")
+		os.Exit(1)
+	}
+	// Offline runs never reach the network, so they report local state only,
+	// like --status.
+	statusMode := *statusFlag || *offlineFlag
+	if *cleanFlag && (*cloneOnlyFlag || statusMode) {
 		fmt.Fprintln(os.Stderr, "error: --clean is only available with the default sync mode")
 		os.Exit(1)
 	}
+	if *adoptRenamesFlag && (*cloneOnlyFlag || statusMode) {
+		fmt.Fprintln(os.Stderr, "error: --adopt-renames is only available with the default sync mode")
+		os.Exit(1)
+	}
+	if *migrateDefaultBranchFlag && (*cloneOnlyFlag || statusMode) {
+		fmt.Fprintln(os.Stderr, "error: --migrate-default-branch is only available with the default sync mode")
+		os.Exit(1)
+	}
+	if *outputFlag != "text" && *outputFlag != "json" {
+		fmt.Fprintln(os.Stderr, "error: --output must be text or json")
+		os.Exit(1)
+	}
+	if *jobsFlag < 0 {
+		fmt.Fprintln(os.Stderr, "error: --jobs must not be negative")
+		os.Exit(1)
+	}
 
 	if *versionFlag {
 		fmt.Println(versionString(Version))
 		os.Exit(0)
 	}
 
-	useColor := !*noColorFlag && output.ShouldColor()
-	printer := output.NewPrinter(useColor, int(verbosity), *noProgressFlag)
+	// In JSON mode stdout is reserved for the report document, so human-readable
+	// output moves to stderr without color or the live progress bar.
+	jsonOutput := *outputFlag == "json"
+	useColor := !*noColorFlag && !jsonOutput && output.ShouldColor()
+	printer := output.NewPrinter(useColor, int(verbosity), *noProgressFlag || jsonOutput)
+	if jsonOutput {
+		printer.SetOutput(os.Stderr)
+	}
 
 	// Startup gate: check for dotfile
 	exePath, err := os.Executable()
@@ -124,124 +170,301 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Resolve token and create GitHub client
-	token := github.ResolveToken()
-	client := github.NewClient(token, printer.Verbose, printer.Trace)
+	// Open the event log, if requested. A nil sink discards events.
+	var sink *events.Sink
+	if *eventsFlag != "" {
+		sink, err = events.Open(*eventsFlag)
+		if err != nil {
+			printer.SystemError("events", err)
+			os.Exit(1)
+		}
+	}
 
-	var allRepos []model.RepoInfo
-	if cfg.IsUserMode() {
-		authUser, authUserErr := client.GetAuthenticatedUser()
-		if authUserErr == nil && authUser == cfg.User {
-			allRepos, err = client.ListOwnRepos()
-		} else {
-			if cfg.ShouldIncludePrivate() {
-				if authUserErr != nil {
-					printer.Verbose("warning: could not verify authenticated user (%v); private repositories may not be included", authUserErr)
-				} else {
-					printer.Verbose("warning: configured user %q does not match authenticated user %q; private repositories will not be included", cfg.User, authUser)
-				}
-			}
-			allRepos, err = client.ListUserRepos(cfg.User)
+	// Resolve API endpoint and token, then create GitHub client
+	apiURL := github.ResolveAPIURL(cfg.APIURL)
+	apiHost := github.APIHost(apiURL)
+	printer.Verbose("Using GitHub API %s (host %s)", apiURL, apiHost)
+	var token string
+	if cfg.GitHubApp == nil {
+		token = github.ResolveToken(apiHost)
+	}
+	client := github.NewClient(apiURL, token, printer.Verbose, printer.Trace)
+	client.SetRateLimitWait(cfg.RateLimitWait())
+	if app := cfg.GitHubApp; app != nil {
+		key, err := github.LoadAppKey(app.PrivateKeyPath)
+		if err != nil {
+			printer.ConfigError(err)
+			os.Exit(1)
 		}
-	} else {
-		allRepos, err = client.ListOrgRepos(cfg.Organization)
+		client.UseApp(github.AppAuth{AppID: app.AppID, InstallationID: app.InstallationID, Key: key})
+		printer.Verbose("Authenticating as GitHub App %d installation %d", app.AppID, app.InstallationID)
 	}
+
+	// Responses are cached next to the dotfile for conditional requests and --offline.
+	cache, err := github.LoadCache(dotfileName + "-cache.json")
 	if err != nil {
-		printer.AuthError(err)
-		os.Exit(1)
+		printer.Verbose("warning: %v; starting with an empty cache", err)
+	}
+	client.SetCache(cache)
+	client.SetOffline(*offlineFlag)
+	if sink != nil {
+		client.Observe(func(rec github.APIRequestRecord) {
+			sink.Emit(events.APIRequest, "", rec)
+		})
 	}
 
-	// Filter repos
-	included, excludedNames := github.FilterRepos(allRepos, cfg)
-	printer.Verbose("Found %d repositories (%d included, %d excluded)", len(allRepos), len(included), len(excludedNames))
+	// List and filter each owner's repositories
+	owners := cfg.OwnerConfigs()
+	layout := cfg.LayoutDirs()
+	inventories := make([]scanner.OwnerRepos, 0, len(owners))
+	for _, oc := range owners {
+		allRepos, partial, err := listRepos(client, printer, oc)
+		if err != nil {
+			var rateLimitErr *github.RateLimitError
+			var teamErr *github.TeamNotFoundError
+			switch {
+			case errors.As(err, &teamErr):
+				printer.ConfigError(err)
+			case *offlineFlag:
+				printer.SystemError("offline", err)
+			case errors.As(err, &rateLimitErr):
+				printer.SystemError("rate-limit", err)
+			default:
+				printer.AuthError(err)
+			}
+			os.Exit(1)
+		}
+
+		included, excludedNames := github.FilterRepos(allRepos, oc)
+		ownerDir := filepath.Join(oc.Directory, config.ExpandLayout(layout, apiHost, oc.Owner()))
+		for i := range included {
+			included[i].Owner = oc.Owner()
+			included[i].Directory = ownerDir
+		}
+		printer.Verbose("Found %d repositories for %s (%d included, %d excluded)", len(allRepos), oc.Owner(), len(included), len(excludedNames))
+		sink.Emit(events.InventoryLoaded, "", inventoryEvent(oc.Owner(), allRepos, included, excludedNames))
+		inventory := make([]string, len(allRepos))
+		for i, r := range allRepos {
+			inventory[i] = r.Name
+		}
+		inventories = append(inventories, scanner.OwnerRepos{
+			Owner:     oc.Owner(),
+			Directory: ownerDir,
+			Included:  included,
+			Excluded:  excludedNames,
+			Inventory: inventory,
+			Partial:   partial,
+			Config:    oc,
+		})
+	}
+	if err := cache.Save(); err != nil {
+		printer.Verbose("warning: %v", err)
+	}
 
-	// Scan directory
+	// Scan the workspace
 	dir, _ := os.Getwd()
-	scanResult, err := scanner.ScanDirectory(dir, included, excludedNames, cfg)
+	scanResult, err := scanner.ScanWorkspace(dir, layout, inventories)
 	if err != nil {
 		printer.SystemError("scan", err)
 		os.Exit(1)
 	}
+	if len(scanResult.SkippedStale) > 0 {
+		printer.Verbose("Skipping %d stale repositories that are not cloned locally", len(scanResult.SkippedStale))
+	}
 
 	// Create sync engine
 	eng := sync.NewEngine(dir, int(verbosity), printer.Verbose, printer.Trace)
+	eng.Protocol = cfg.Protocol()
+	eng.MigrateDefaultBranch = *migrateDefaultBranchFlag
+	eng.Offline = *offlineFlag
+	if token != "" || client.UsesApp() {
+		// git authenticates HTTPS remotes with the same token as the API
+		exe, err := os.Executable()
+		if err != nil {
+			printer.SystemError("credentials", err)
+			os.Exit(1)
+		}
+		eng.SetCredentials(&sync.GitCredentials{Host: apiHost, Token: client.Token, Helper: exe})
+	}
+	if sink != nil {
+		eng.ObserveGit(func(rec sync.GitCommandRecord) {
+			name, err := filepath.Rel(dir, rec.RepoDir)
+			if err != nil {
+				name = filepath.Base(rec.RepoDir)
+			}
+			sink.Emit(events.GitCommand, name, rec)
+		})
+	}
 
-	// Build lookup map from repo name → RepoInfo
-	repoMap := make(map[string]model.RepoInfo, len(included))
-	for _, r := range included {
-		repoMap[r.Name] = r
+	jobs := cfg.JobCount()
+	if *jobsFlag > 0 {
+		jobs = *jobsFlag
+	}
+	printer.Verbose("Processing repositories with %d parallel job(s)", jobs)
+
+	// Build lookup map from repo path → RepoInfo. When owners clash on a
+	// path, the scanner kept the first owner's repository, so keep it here too.
+	repoMap := make(map[string]model.RepoInfo)
+	ownerSummaries := make(map[string]*model.Summary, len(inventories))
+	for _, inv := range inventories {
+		ownerSummaries[inv.Owner] = &model.Summary{TotalRepos: len(inv.Included)}
+		for _, r := range inv.Included {
+			if _, ok := repoMap[r.Path()]; !ok {
+				repoMap[r.Path()] = r
+			}
+		}
+	}
+	for _, name := range scanResult.SkippedStale {
+		ownerSummaries[repoMap[name].Owner].TotalRepos--
+	}
+	// Unknown clones of missing repositories (renamed on GitHub, or moved
+	// locally) are matched back to them rather than cloned a second time.
+	scanner.MatchRenames(dir, scanResult, repoMap, eng.Git)
+	// Unknown clones of repositories deleted or transferred upstream are
+	// orphans; whether they hold unpushed work tells if they are safe to delete.
+	scanner.MatchOrphans(dir, scanResult, inventories, eng.Git, client)
+	orphanWork := make([]model.RepoResult, len(scanResult.Orphaned))
+	for i, entry := range scanResult.Orphaned {
+		orphanWork[i] = eng.LocalWork(entry.Name)
+		scanResult.Orphaned[i].Unpushed = orphanWork[i].HasUnpushedWork()
+	}
+
+	// ownerSummary returns the counters for the owner of a repository result.
+	ownerSummary := func(result model.RepoResult) *model.Summary {
+		return ownerSummaries[result.Owner]
 	}
 
-	// Summary counters
+	// Workspace-wide counters; per-owner counts are added before printing.
 	var summary model.Summary
-	summary.TotalRepos = len(included)
+
+	mode := "sync"
+	if *cloneOnlyFlag {
+		mode = "clone"
+	} else if statusMode {
+		mode = "status"
+	}
+	multiOwner := len(cfg.Owners) > 0
+	reportOwner := ""
+	if !multiOwner {
+		reportOwner = cfg.Owner()
+	}
+	rep := report.New(mode, reportOwner)
+	rep.AddLocalEntries(scanResult.Collisions)
+	rep.AddLocalEntries(scanResult.Unknown)
+	rep.AddLocalEntries(scanResult.Renamed)
+	rep.AddLocalEntries(scanResult.Orphaned)
+	rep.AddLocalEntries(scanResult.ExcludedButPresent)
+	for _, entries := range [][]model.LocalEntry{scanResult.Collisions, scanResult.Unknown, scanResult.Renamed, scanResult.Orphaned, scanResult.ExcludedButPresent} {
+		for _, entry := range entries {
+			sink.Emit(events.Finding, entry.Name, events.FindingData{Kind: entry.Classification.String(), Detail: entry.Detail})
+		}
+	}
+
+	// Report renamed clones, or with --adopt-renames move them into place so
+	// they are synced below like any other managed clone.
+	for _, entry := range scanResult.Renamed {
+		repo := repoMap[entry.Target]
+		ownerSummaries[repo.Owner].Renamed++
+		if !*adoptRenamesFlag {
+			printer.RepoRenamed(entry.Name, entry.Target, entry.Detail)
+			continue
+		}
+		if err := eng.AdoptRename(entry.Name, repo); err != nil {
+			printer.RepoError(entry.Target, "adopt-error", err)
+			ownerSummaries[repo.Owner].Errors++
+			continue
+		}
+		printer.RepoAdopted(entry.Name, entry.Target)
+		scanResult.ManagedFound = append(scanResult.ManagedFound, entry.Target)
+	}
 
 	if *cloneOnlyFlag {
 		// Clone-only mode: only clone missing repos, skip everything else
 		printer.StartRepoProgress(len(scanResult.ManagedMissing))
 
-		for _, name := range scanResult.ManagedMissing {
-			repo := repoMap[name]
-			result := eng.CloneRepo(repo)
-			handleResult(printer, result, &summary)
-			printer.AdvanceRepoProgress()
-		}
+		sync.RunOrdered(len(scanResult.ManagedMissing), jobs, func(i int) model.RepoResult {
+			defer printer.AdvanceRepoProgress()
+			sink.Emit(events.RepoStarted, scanResult.ManagedMissing[i], nil)
+			return eng.CloneRepo(repoMap[scanResult.ManagedMissing[i]])
+		}, func(_ int, result model.RepoResult) {
+			rep.AddRepo(result)
+			sink.Emit(events.RepoFinished, result.Name, report.NewRepo(result))
+			handleResult(printer, result, ownerSummary(result))
+		})
 
 		printer.FinishRepoProgress()
-	} else if *statusFlag {
+	} else if statusMode {
 		// Status mode: read-only check of existing repos
 		printer.StartRepoProgress(len(scanResult.ManagedFound))
 
-		for _, name := range scanResult.ManagedFound {
-			repo := repoMap[name]
-			result := eng.StatusRepo(repo)
+		sync.RunOrdered(len(scanResult.ManagedFound), jobs, func(i int) model.RepoResult {
+			defer printer.AdvanceRepoProgress()
+			sink.Emit(events.RepoStarted, scanResult.ManagedFound[i], nil)
+			return eng.StatusRepo(repoMap[scanResult.ManagedFound[i]])
+		}, func(_ int, result model.RepoResult) {
+			rep.AddRepo(result)
+			sink.Emit(events.RepoFinished, result.Name, report.NewRepo(result))
+			counts := ownerSummary(result)
+			reportProtocolMismatch(printer, sink, result, cfg.Protocol())
+			reportHeadState(printer, sink, result, counts)
 			switch result.Action {
 			case model.ActionDirty:
 				printer.RepoStatusDirty(result.Name, result.CurrentBranch, result.DefaultBranch, result.StatusOutput)
-				summary.Dirty++
+				counts.Dirty++
 			case model.ActionBranchDrift:
 				printer.RepoStatusBranchDrift(result.Name, result.CurrentBranch, result.DefaultBranch)
-				summary.BranchDrift++
+				counts.BranchDrift++
+			case model.ActionRemoteMismatch:
+				printer.RepoRemoteMismatch(result.Name, result.Error)
+				counts.RemoteMismatch++
 			case model.ActionFetchError:
-				printer.RepoError(result.Name, result.Action.String(), result.Error)
-				summary.Errors++
+				printer.RepoGitError(result.Name, result.Action.String(), result.Error, result.ErrorCategory)
+				counts.Errors++
 			}
-			printer.AdvanceRepoProgress()
-		}
+			reportUnpushedWork(printer, sink, result, counts)
+		})
 
 		printer.FinishRepoProgress()
 
-		printer.StatusSummary(summary.TotalRepos, summary.Dirty, summary.BranchDrift)
-		os.Exit(0)
 	} else {
 		// Default mode: full sync
 		summary.UnknownFolders = len(scanResult.Unknown)
+		summary.Orphaned = len(scanResult.Orphaned)
 		summary.ExcludedButPresent = len(scanResult.ExcludedButPresent)
-		summary.Errors = len(scanResult.Collisions)
+		// A collision keeps one of an owner's repositories from syncing, so it
+		// counts against that owner.
+		for _, entry := range scanResult.Collisions {
+			ownerSummaries[entry.Owner].Errors++
+		}
 
 		repoWorkTotal := len(scanResult.ManagedMissing) + len(scanResult.ManagedFound)
 		printer.StartRepoProgress(repoWorkTotal)
-		// Clone missing repos
-		for _, name := range scanResult.ManagedMissing {
-			repo := repoMap[name]
-			result := eng.CloneRepo(repo)
-			handleResult(printer, result, &summary)
-			if *cleanFlag && result.Action == model.ActionCloned {
-				cleanRepoIgnoredContent(eng, dir, name, printer, *forceFlag, *dryRunFlag, &summary)
+		// Clone missing repos, then process existing repos. Git work runs on the
+		// worker pool; reporting and cleanup (which may prompt) run in order on
+		// this goroutine.
+		missingCount := len(scanResult.ManagedMissing)
+		sync.RunOrdered(repoWorkTotal, jobs, func(i int) model.RepoResult {
+			defer printer.AdvanceRepoProgress()
+			if i < missingCount {
+				sink.Emit(events.RepoStarted, scanResult.ManagedMissing[i], nil)
+				return eng.CloneRepo(repoMap[scanResult.ManagedMissing[i]])
 			}
-			printer.AdvanceRepoProgress()
-		}
-
-		// Process existing repos
-		for _, name := range scanResult.ManagedFound {
-			repo := repoMap[name]
-			result := eng.ProcessRepo(repo)
-			handleResult(printer, result, &summary)
-			if *cleanFlag {
-				cleanRepoIgnoredContent(eng, dir, name, printer, *forceFlag, *dryRunFlag, &summary)
+			sink.Emit(events.RepoStarted, scanResult.ManagedFound[i-missingCount], nil)
+			return eng.ProcessRepo(repoMap[scanResult.ManagedFound[i-missingCount]])
+		}, func(i int, result model.RepoResult) {
+			rep.AddRepo(result)
+			sink.Emit(events.RepoFinished, result.Name, report.NewRepo(result))
+			counts := ownerSummary(result)
+			reportProtocolMismatch(printer, sink, result, cfg.Protocol())
+			reportHeadState(printer, sink, result, counts)
+			handleResult(printer, result, counts)
+			reportUnpushedWork(printer, sink, result, counts)
+			// Repos whose origin points elsewhere are not ours to clean, and repos
+			// mid-operation or detached are left exactly as they are.
+			if *cleanFlag && !leftUntouched(result.Action) && (i >= missingCount || result.Action == model.ActionCloned) {
+				cleanRepoIgnoredContent(eng, dir, result.Name, printer, rep, sink, *forceFlag, *dryRunFlag, counts)
 			}
-			printer.AdvanceRepoProgress()
-		}
+		})
 
 		printer.FinishRepoProgress()
 
@@ -255,6 +478,11 @@ func main() {
 			printer.UnknownFolder(entry.Name)
 		}
 
+		// Report orphaned clones with any work that exists only locally
+		for i, entry := range scanResult.Orphaned {
+			printer.OrphanedFolder(entry.Name, entry.Detail, orphanWork[i].UnpushedBranches(), orphanWork[i].Stashes)
+		}
+
 		// Report excluded-but-present
 		for _, entry := range scanResult.ExcludedButPresent {
 			printer.ExcludedButPresent(entry.Name)
@@ -262,22 +490,43 @@ func main() {
 
 	}
 
-	// Print summary
-	printer.Summary(
-		summary.TotalRepos,
-		summary.Cloned,
-		summary.Updated,
-		summary.Dirty,
-		summary.BranchDrift,
-		summary.UnknownFolders,
-		summary.ExcludedButPresent,
-		summary.Errors,
-	)
+	// Print summary, broken down per owner when the workspace uses owners
+	for _, inv := range inventories {
+		summary.Add(*ownerSummaries[inv.Owner])
+	}
+	if statusMode {
+		printer.StatusSummary(summary)
+	} else {
+		printer.Summary(summary)
+	}
+	if multiOwner {
+		for _, inv := range inventories {
+			if statusMode {
+				printer.OwnerStatusSummary(inv.Owner, *ownerSummaries[inv.Owner])
+			} else {
+				printer.OwnerSummary(inv.Owner, *ownerSummaries[inv.Owner])
+			}
+			rep.AddOwner(inv.Owner, inv.Directory, *ownerSummaries[inv.Owner])
+		}
+	}
+
+	sink.Emit(events.Summary, "", report.NewSummary(summary))
+	if err := sink.Close(); err != nil {
+		printer.SystemError("events", err)
+	}
+
+	if jsonOutput {
+		rep.SetSummary(summary)
+		if err := rep.Write(os.Stdout); err != nil {
+			printer.SystemError("output", err)
+			os.Exit(1)
+		}
+	}
 }
 
 // cleanRepoIgnoredContent is the final phase for one repository. It runs
 // immediately after that repository's normal sync work.
-func cleanRepoIgnoredContent(eng *sync.Engine, baseDir, name string, printer *output.Printer, force, dryRun bool, summary *model.Summary) {
+func cleanRepoIgnoredContent(eng *sync.Engine, baseDir, name string, printer *output.Printer, rep *report.Report, sink *events.Sink, force, dryRun bool, summary *model.Summary) {
 	repoDir := filepath.Join(baseDir, name)
 	paths, err := eng.Git.IgnoredPaths(repoDir)
 	if err != nil {
@@ -296,8 +545,15 @@ func cleanRepoIgnoredContent(eng *sync.Engine, baseDir, name string, printer *ou
 		return
 	}
 
-	files, _ := cleanup.Counts(targets)
-	printer.RepoCleanupPlanned(name, files, cleanup.TotalSize(targets), dryRun)
+	files, dirs := cleanup.Counts(targets)
+	plan := report.Cleanup{Repo: name, Files: files, Dirs: dirs, Bytes: cleanup.TotalSize(targets), DryRun: dryRun}
+	for _, target := range targets {
+		plan.Paths = append(plan.Paths, target.Path)
+	}
+	defer func() { rep.AddCleanup(plan) }()
+	sink.Emit(events.CleanupPlanned, name, plan)
+
+	printer.RepoCleanupPlanned(name, files, plan.Bytes, dryRun)
 	if dryRun {
 		for _, target := range targets {
 			printer.Verbose("cleanup: %s would remove %s", name, target.Path)
@@ -307,11 +563,20 @@ func cleanRepoIgnoredContent(eng *sync.Engine, baseDir, name string, printer *ou
 	if !force && !printer.ConfirmCleanup(name) {
 		return
 	}
+	plan.Failed = removeCleanupTargets(repoDir, name, targets, printer, summary)
+	plan.Removed = len(plan.Failed) == 0
+}
 
+// removeCleanupTargets deletes each target and prunes the directories it
+// leaves empty, reporting failures as cleanup errors. It returns the paths of
+// the targets that could not be removed.
+func removeCleanupTargets(repoDir, name string, targets []cleanup.Target, printer *output.Printer, summary *model.Summary) []string {
+	var failed []string
 	for _, target := range targets {
 		if err := cleanup.Remove(repoDir, target); err != nil {
 			printer.RepoError(name, "cleanup-error", err)
 			summary.Errors++
+			failed = append(failed, target.Path)
 			continue
 		}
 		printer.Verbose("cleanup: %s removed %s", name, target.Path)
@@ -325,10 +590,140 @@ func cleanRepoIgnoredContent(eng *sync.Engine, baseDir, name string, printer *ou
 			printer.Verbose("cleanup: %s removed %s/", name, dir)
 		}
 	}
+	return failed
+}
+
+// reportProtocolMismatch prints a finding when an existing clone's origin uses
+// a different protocol than the configured clone_protocol.
+func reportProtocolMismatch(printer *output.Printer, sink *events.Sink, result model.RepoResult, expected string) {
+	if result.ProtocolMismatch {
+		actual := sync.ParseRemote(result.RemoteURL).Protocol
+		printer.RepoProtocolMismatch(result.Name, actual, expected)
+		sink.Emit(events.Finding, result.Name, events.FindingData{
+			Kind:   "protocol-mismatch",
+			Detail: fmt.Sprintf("origin uses %s, clone_protocol is %s", actual, expected),
+		})
+	}
+}
+
+// leftUntouched reports whether action means the repository must not be
+// modified at all, including by cleanup.
+func leftUntouched(action model.RepoAction) bool {
+	return action == model.ActionRemoteMismatch || action == model.ActionDetachedHead || action == model.ActionInProgress
+}
+
+// reportHeadState prints and counts repositories left untouched because HEAD
+// is detached or a git operation is in progress.
+func reportHeadState(printer *output.Printer, sink *events.Sink, result model.RepoResult, summary *model.Summary) {
+	switch result.Action {
+	case model.ActionDetachedHead:
+		printer.RepoDetachedHead(result.Name)
+		summary.DetachedHead++
+		sink.Emit(events.Finding, result.Name, events.FindingData{Kind: "detached-head"})
+	case model.ActionInProgress:
+		printer.RepoInProgress(result.Name, result.CurrentBranch, result.Operation)
+		summary.InProgress++
+		sink.Emit(events.Finding, result.Name, events.FindingData{
+			Kind:   "in-progress",
+			Detail: result.Operation + " in progress",
+		})
+	}
+}
+
+// reportUnpushedWork prints and counts work that exists only in the local
+// clone, so it is not lost when the clone is deleted.
+func reportUnpushedWork(printer *output.Printer, sink *events.Sink, result model.RepoResult, summary *model.Summary) {
+	if !result.HasUnpushedWork() {
+		return
+	}
+	branches := result.UnpushedBranches()
+	printer.RepoUnpushed(result.Name, branches, result.Stashes)
+	summary.Unpushed++
+	details := make([]string, 0, len(branches)+1)
+	for _, b := range branches {
+		details = append(details, output.DescribeBranchRisk(b))
+	}
+	if result.Stashes > 0 {
+		details = append(details, fmt.Sprintf("stash entries: %d", result.Stashes))
+	}
+	sink.Emit(events.Finding, result.Name, events.FindingData{Kind: "unpushed", Detail: strings.Join(details, "; ")})
+}
+
+// listRepos lists the repositories of one owner: an organization, narrowed
+// to its teams when teams is set, or a user. A GitHub App installation lists
+// the owner's repositories it has been granted. partial reports that the
+// listing may omit repositories the owner has: those not granted to the app,
+// outside the teams, or private to a user other than the authenticated one.
+func listRepos(client *github.Client, printer *output.Printer, cfg *config.Config) (repos []model.RepoInfo, partial bool, err error) {
+	if client.UsesApp() {
+		repos, err = client.ListInstallationRepos(cfg.Owner())
+		if err == nil && len(cfg.Teams) > 0 {
+			repos, err = restrictToTeams(client, cfg, repos)
+		}
+		return repos, true, err
+	}
+	if !cfg.IsUserMode() {
+		repos, err = client.ListOrgRepos(cfg.Organization)
+		if err == nil && len(cfg.Teams) > 0 {
+			repos, err = restrictToTeams(client, cfg, repos)
+		}
+		return repos, len(cfg.Teams) > 0, err
+	}
+
+	authUser, authUserErr := client.GetAuthenticatedUser()
+	if authUserErr == nil && authUser == cfg.User {
+		repos, err = client.ListOwnRepos()
+		return repos, false, err
+	}
+	if cfg.ShouldIncludePrivate() {
+		if authUserErr != nil {
+			printer.Verbose("warning: could not verify authenticated user (%v); private repositories may not be included", authUserErr)
+		} else {
+			printer.Verbose("warning: configured user %q does not match authenticated user %q; private repositories will not be included", cfg.User, authUser)
+		}
+	}
+	repos, err = client.ListUserRepos(cfg.User)
+	return repos, true, err
+}
+
+// restrictToTeams narrows the org listing to the repositories of the
+// configured teams.
+func restrictToTeams(client *github.Client, cfg *config.Config, repos []model.RepoInfo) ([]model.RepoInfo, error) {
+	teamRepos := make([][]model.RepoInfo, len(cfg.Teams))
+	for i, slug := range cfg.Teams {
+		listed, err := client.ListTeamRepos(cfg.Organization, slug)
+		if err != nil {
+			return nil, err
+		}
+		teamRepos[i] = listed
+	}
+	return github.RestrictToTeams(repos, teamRepos, cfg.MatchAllTeams()), nil
+}
+
+// inventoryEvent is the payload of the inventory-loaded event.
+func inventoryEvent(owner string, all, included []model.RepoInfo, excluded []string) any {
+	names := make([]string, len(included))
+	for i, r := range included {
+		names[i] = r.Path()
+	}
+	if excluded == nil {
+		excluded = []string{}
+	}
+	return struct {
+		Owner    string   `json:"owner"`
+		Total    int      `json:"total"`
+		Included []string `json:"included"`
+		Excluded []string `json:"excluded"`
+	}{owner, len(all), names, excluded}
 }
 
 // handleResult maps a RepoResult to the appropriate printer call and updates summary counts.
 func handleResult(printer *output.Printer, result model.RepoResult, summary *model.Summary) {
+	// A drift corrected before the pull was skipped or failed still counts.
+	if result.Action != model.ActionBranchDrift && driftCorrected(result) {
+		printer.RepoBranchDrift(result.Name, result.CurrentBranch, result.DefaultBranch, false)
+		summary.BranchDrift++
+	}
 	switch result.Action {
 	case model.ActionCloned:
 		printer.RepoCloned(result.Name)
@@ -341,22 +736,49 @@ func handleResult(printer *output.Printer, result model.RepoResult, summary *mod
 		for i, f := range result.DirtyFiles {
 			files[i] = output.DirtyFileInfo{
 				Path:     f.Path,
+				OrigPath: f.OrigPath,
 				Staged:   f.Staged,
 				Unstaged: f.Unstaged,
+				Unmerged: f.Kind == model.ChangeUnmerged,
 			}
 		}
 		printer.RepoDirty(result.Name, result.CurrentBranch, result.DefaultBranch, files, result.Additions, result.Deletions)
 		summary.Dirty++
+		// A rename upstream is reported too, but never migrated while dirty.
+		if result.PreviousDefault != "" {
+			printer.RepoDefaultBranchRenamed(result.Name, result.PreviousDefault, result.DefaultBranch, false, result.DefaultExisted, false)
+			summary.DefaultBranchRenamed++
+		}
+	case model.ActionDefaultBranchRenamed:
+		printer.RepoDefaultBranchRenamed(result.Name, result.PreviousDefault, result.DefaultBranch, result.BranchMigrated, result.DefaultExisted, result.Updated)
+		if result.Updated {
+			summary.Updated++
+		}
+		summary.DefaultBranchRenamed++
 	case model.ActionBranchDrift:
 		printer.RepoBranchDrift(result.Name, result.CurrentBranch, result.DefaultBranch, result.Updated)
 		if result.Updated {
 			summary.Updated++
 		}
 		summary.BranchDrift++
+	case model.ActionRemoteMismatch:
+		printer.RepoRemoteMismatch(result.Name, result.Error)
+		summary.RemoteMismatch++
+	case model.ActionDiverged:
+		printer.RepoDiverged(result.Name, result.CurrentBranch, result.Ahead, result.Behind)
+		summary.Diverged++
 	case model.ActionAlreadyCurrent:
 		printer.Verbose("%s is already up to date", result.Name)
 	case model.ActionCloneError, model.ActionFetchError, model.ActionCheckoutError, model.ActionPullError, model.ActionSubmoduleError:
-		printer.RepoError(result.Name, result.Action.String(), result.Error)
+		printer.RepoGitError(result.Name, result.Action.String(), result.Error, result.ErrorCategory)
 		summary.Errors++
 	}
 }
+
+// driftCorrected reports whether the engine checked out the default branch of
+// a repository that was on another branch. The current branch only changes
+// when that checkout succeeds; a migrated default branch is reported as a
+// rename instead.
+func driftCorrected(result model.RepoResult) bool {
+	return result.BranchDrift && !result.BranchMigrated && result.CurrentBranch == result.DefaultBranch
+}
@@ -1,9 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/cleanup"
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/config"
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/github"
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/model"
+	"github.com/UnitVectorY-Labs/ghorgsync/internal/output"
 )
 
 func TestNormalizedVersion(t *testing.T) {
@@ -48,3 +63,127 @@ func TestVersionString(t *testing.T) {
 		t.Fatalf("versionString should end with ')': %q", got)
 	}
 }
+
+func TestHandleResult_CountsCorrectedDrift(t *testing.T) {
+	tests := []struct {
+		name   string
+		result model.RepoResult
+		drift  int
+	}{
+		{name: "drift", result: model.RepoResult{Action: model.ActionBranchDrift, BranchDrift: true, CurrentBranch: "main"}, drift: 1},
+		{name: "drift then diverged", result: model.RepoResult{Action: model.ActionDiverged, BranchDrift: true, CurrentBranch: "main", Ahead: 1, Behind: 1}, drift: 1},
+		{name: "drift then pull error", result: model.RepoResult{Action: model.ActionPullError, BranchDrift: true, CurrentBranch: "main", Error: errors.New("pull failed")}, drift: 1},
+		{name: "checkout failed", result: model.RepoResult{Action: model.ActionCheckoutError, BranchDrift: true, CurrentBranch: "feature", Error: errors.New("checkout failed")}},
+		{name: "dirty on another branch", result: model.RepoResult{Action: model.ActionDirty, BranchDrift: true, CurrentBranch: "feature"}},
+		{name: "migrated then diverged", result: model.RepoResult{Action: model.ActionDiverged, BranchDrift: true, BranchMigrated: true, CurrentBranch: "main"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			printer := output.NewPrinter(false, 0, true)
+			var out bytes.Buffer
+			printer.SetOutput(&out)
+			tt.result.Name, tt.result.DefaultBranch = "repo", "main"
+			var summary model.Summary
+
+			handleResult(printer, tt.result, &summary)
+
+			if summary.BranchDrift != tt.drift {
+				t.Errorf("BranchDrift = %d, want %d; output:\n%s", summary.BranchDrift, tt.drift, out.String())
+			}
+			if got := strings.Count(out.String(), "branch-drift"); got != tt.drift {
+				t.Errorf("printed %d branch-drift lines, want %d:\n%s", got, tt.drift, out.String())
+			}
+		})
+	}
+}
+
+func TestRemoveCleanupTargets_PartialFailure(t *testing.T) {
+	repoDir := t.TempDir()
+	for _, name := range []string{"build.log", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(repoDir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targets := []cleanup.Target{
+		{Path: "build.log"},
+		// The parent is a file, so the removal fails even for root.
+		{Path: "notes.txt/cache"},
+	}
+	printer := output.NewPrinter(false, 0, true)
+	var out bytes.Buffer
+	printer.SetOutput(&out)
+	var summary model.Summary
+
+	failed := removeCleanupTargets(repoDir, "repo", targets, printer, &summary)
+
+	if len(failed) != 1 || failed[0] != "notes.txt/cache" {
+		t.Fatalf("expected only notes.txt/cache to fail, got %v", failed)
+	}
+	if summary.Errors != 1 || !strings.Contains(out.String(), "cleanup-error") {
+		t.Errorf("expected one cleanup-error, got %d errors:\n%s", summary.Errors, out.String())
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, "build.log")); !os.IsNotExist(err) {
+		t.Errorf("expected build.log to be removed, got %v", err)
+	}
+}
+
+// inventoryServer serves the listings listRepos chooses between; the
+// authenticated user is "octocat".
+func inventoryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			fmt.Fprint(w, `{"login":"octocat"}`)
+		case "/user/repos", "/users/someone/repos", "/orgs/acme/repos", "/orgs/acme/teams/platform/repos":
+			fmt.Fprint(w, `[{"name":"repo","owner":{"login":"acme"}}]`)
+		case "/app/installations/2/access_tokens":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"token":"ghs_x","expires_at":"2999-01-01T00:00:00Z"}`)
+		case "/installation/repositories":
+			fmt.Fprint(w, `{"repositories":[{"name":"repo","owner":{"login":"acme"}}]}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestListRepos_ReportsPartialInventories(t *testing.T) {
+	server := inventoryServer(t)
+	printer := output.NewPrinter(false, 0, true)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *config.Config
+		app     bool
+		partial bool
+	}{
+		{name: "organization", cfg: &config.Config{Organization: "acme"}},
+		{name: "authenticated user", cfg: &config.Config{User: "octocat"}},
+		{name: "other user, public repositories only", cfg: &config.Config{User: "someone"}, partial: true},
+		{name: "organization teams", cfg: &config.Config{Organization: "acme", Teams: []string{"platform"}}, partial: true},
+		{name: "app installation", cfg: &config.Config{Organization: "acme"}, app: true, partial: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := github.NewClient(server.URL, "token", nil, nil)
+			if tt.app {
+				client.UseApp(github.AppAuth{AppID: 1, InstallationID: 2, Key: key})
+			}
+			repos, partial, err := listRepos(client, printer, tt.cfg)
+			if err != nil || len(repos) != 1 {
+				t.Fatalf("listRepos returned %d repos, %v", len(repos), err)
+			}
+			if partial != tt.partial {
+				t.Errorf("partial = %t, want %t", partial, tt.partial)
+			}
+		})
+	}
+}